@@ -21,15 +21,25 @@ const App: React.FC = () => {
 
   useEffect(() => {
     if (currentRoom && isAuthenticated) {
-      const websocket = createWebSocket(currentRoom.id);
-      websocket.onmessage = (event) => {
-        const message = JSON.parse(event.data);
-        handleWebSocketMessage(message);
-      };
-      setWs(websocket);
+      let websocket: WebSocket | null = null;
+      let cancelled = false;
+
+      createWebSocket(currentRoom.id).then((socket) => {
+        if (cancelled) {
+          socket.close();
+          return;
+        }
+        websocket = socket;
+        websocket.onmessage = (event) => {
+          const message = JSON.parse(event.data);
+          handleWebSocketMessage(message);
+        };
+        setWs(websocket);
+      });
 
       return () => {
-        websocket.close();
+        cancelled = true;
+        websocket?.close();
       };
     }
   }, [currentRoom, isAuthenticated]);
@@ -56,8 +66,8 @@ const App: React.FC = () => {
       case 'start_poll':
         // Handle poll start
         break;
-      case 'vote':
-        // Handle vote
+      case 'results_update':
+        // Handle live results
         break;
       case 'end_poll':
         // Handle poll end
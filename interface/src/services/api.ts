@@ -8,6 +8,7 @@ import {
   Room,
   User,
   VoteRequest,
+  WebSocketTicket,
 } from '../types';
 
 const API_URL = process.env.REACT_APP_API_URL || 'http://localhost:8080/api';
@@ -81,8 +82,11 @@ export const polls = {
 };
 
 // WebSocket connection
-export const createWebSocket = (roomId: string): WebSocket => {
-  const token = localStorage.getItem('token');
+// The JWT never goes into the URL: a single-use ticket is fetched first and
+// offered to the server as a subprotocol during the upgrade.
+export const createWebSocket = async (roomId: string): Promise<WebSocket> => {
+  const response = await api.post<WebSocketTicket>(`/rooms/${roomId}/ws-ticket`);
+  const { ticket, protocol } = response.data;
   const wsUrl = `${process.env.REACT_APP_WS_URL || 'ws://localhost:8080'}/ws/rooms/${roomId}`;
-  return new WebSocket(wsUrl + (token ? `?token=${token}` : ''));
+  return new WebSocket(wsUrl, [protocol, `ticket.${ticket}`]);
 }; 
\ No newline at end of file
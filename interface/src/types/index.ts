@@ -50,10 +50,17 @@ export interface Vote {
 }
 
 export interface WebSocketMessage {
-  type: 'vote' | 'start_poll' | 'end_poll';
+  type: 'results_update' | 'start_poll' | 'end_poll' | 'ack' | 'error';
+  id?: string;
   payload: any;
 }
 
+export interface WebSocketTicket {
+  ticket: string;
+  expires_at: string;
+  protocol: string;
+}
+
 export interface AuthResponse {
   token: string;
   user: User;
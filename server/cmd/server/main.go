@@ -9,6 +9,7 @@ import (
 	"github.com/joho/godotenv"
 	"polling-app/internal/auth"
 	"polling-app/internal/poll"
+	"polling-app/internal/qna"
 	"polling-app/internal/room"
 	"polling-app/internal/websocket"
 	"polling-app/pkg/database"
@@ -23,6 +24,22 @@ func main() {
 	// Initialize database
 	database.InitDB()
 
+	// Re-arm poll timers persisted before the last shutdown
+	poll.StartTimers()
+
+	// Purge deleted rooms once their retention period is over
+	room.StartRetentionSweep()
+
+	// Register WebSocket commands
+	poll.RegisterCommands()
+	qna.RegisterCommands()
+
+	// Track who is connected to each room and who has gone idle
+	websocket.StartPresence()
+
+	// Drop dead WebSocket connections and free rooms nobody is connected to
+	websocket.StartReaper()
+
 	// Initialize router
 	router := gin.Default()
 
@@ -65,16 +82,47 @@ func main() {
 			rooms := protected.Group("/rooms")
 			{
 				rooms.POST("/", room.CreateRoom)
-				rooms.GET("/:id", room.GetRoom)
 				rooms.POST("/:id/join", room.JoinRoom)
+				rooms.PUT("/:id/members/:userId/role", room.SetRole)
+				rooms.DELETE("/:id/members/:userId/role", room.RevokeRole)
+				rooms.POST("/:id/transfer", room.TransferOwnership)
+				rooms.POST("/:id/members/:userId/kick", room.KickMember)
+				rooms.POST("/:id/members/:userId/ban", room.BanMember)
+				rooms.POST("/:id/members/:userId/mute", room.MuteMember)
+				rooms.DELETE("/:id/members/:userId/mute", room.UnmuteMember)
+				rooms.GET("/:id/bans", room.ListBans)
+				rooms.DELETE("/:id/bans/:banId", room.LiftBan)
+				rooms.POST("/:id/close", room.CloseRoom)
+				rooms.POST("/:id/reopen", room.ReopenRoom)
+				rooms.POST("/:id/archive", room.ArchiveRoom)
+				rooms.DELETE("/:id", room.DeleteRoom)
+				rooms.GET("/:id/invites", room.ListInvites)
+				rooms.POST("/:id/invites", room.CreateInvite)
+				rooms.PUT("/:id/invites/:inviteId", room.UpdateInvite)
+				rooms.POST("/:id/invites/:inviteId/rotate", room.RotateInvite)
+				rooms.DELETE("/:id/invites/:inviteId", room.RevokeInvite)
+				rooms.PUT("/:id/join-policy", room.UpdateJoinPolicy)
+				rooms.GET("/:id/join-requests", room.ListJoinRequests)
+				rooms.POST("/:id/join-requests/:requestId/approve", room.ApproveJoinRequest)
+				rooms.POST("/:id/join-requests/:requestId/deny", room.DenyJoinRequest)
+				rooms.GET("/:id/capacity", room.GetCapacity)
+				rooms.PUT("/:id/capacity", room.UpdateCapacity)
 			}
 
 			// Poll routes
 			polls := protected.Group("/polls")
 			{
 				polls.POST("/", poll.CreatePoll)
-				polls.POST("/:id/vote", poll.Vote)
-				polls.GET("/:id/results", poll.GetResults)
+				polls.POST("/:id/start", poll.StartPoll)
+				polls.POST("/:id/schedule", poll.SchedulePoll)
+				polls.POST("/:id/pause", poll.PausePoll)
+				polls.POST("/:id/resume", poll.ResumePoll)
+				polls.POST("/:id/extend", poll.ExtendPoll)
+				polls.POST("/:id/end", poll.EndPoll)
+				polls.POST("/:id/reveal", poll.RevealPoll)
+				polls.POST("/:id/runoff", poll.ShowRunoffRound)
+				polls.POST("/:id/responses/:responseId/moderate", poll.ModerateResponse)
+				polls.GET("/:id/votes", poll.GetVotes)
 			}
 		}
 
@@ -82,12 +130,32 @@ func main() {
 		optionalAuth := api.Group("/")
 		optionalAuth.Use(auth.OptionalAuthMiddleware())
 		{
-			// Add routes that can be accessed by both authenticated and guest users
+			// Guests join with an invite code and receive a guest token
+			optionalAuth.POST("/guests/join", room.JoinAsGuest)
+			optionalAuth.POST("/auth/upgrade", auth.UpgradeGuest)
+
+			optionalAuth.GET("/rooms/:id", room.GetRoom)
+			optionalAuth.GET("/rooms/:id/members", room.ListMembers)
+			optionalAuth.GET("/rooms/:id/join-request", room.GetJoinRequest)
+			optionalAuth.POST("/rooms/:id/leave", room.LeaveRoom)
+			optionalAuth.GET("/rooms/:id/presence", room.GetPresence)
+			optionalAuth.POST("/rooms/:id/ws-ticket", auth.IssueWebSocketTicket)
+			optionalAuth.GET("/rooms/:id/leaderboard", poll.GetLeaderboard)
+			optionalAuth.POST("/polls/:id/vote", poll.Vote)
+			optionalAuth.GET("/polls/:id/results", poll.GetResults)
+			optionalAuth.GET("/polls/:id/responses", poll.GetResponses)
+
+			// Q&A board
+			optionalAuth.GET("/rooms/:id/questions", qna.GetQuestions)
+			optionalAuth.POST("/rooms/:id/questions", qna.AskQuestion)
+			optionalAuth.POST("/questions/:id/upvote", qna.UpvoteQuestion)
+			optionalAuth.DELETE("/questions/:id/upvote", qna.RemoveUpvote)
+			optionalAuth.POST("/questions/:id/moderate", qna.ModerateQuestion)
 		}
 	}
 
-	// WebSocket endpoint (with optional auth)
-	router.GET("/ws/rooms/:id", auth.OptionalAuthMiddleware(), websocket.HandleWebSocket)
+	// WebSocket endpoint (authenticated with a single-use ticket)
+	router.GET("/ws/rooms/:id", auth.WebSocketAuthMiddleware(), websocket.HandleWebSocket)
 
 	// Start server
 	port := os.Getenv("PORT")
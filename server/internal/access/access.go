@@ -0,0 +1,201 @@
+package access
+
+import (
+	"errors"
+	"net/http"
+
+	"gorm.io/gorm"
+	"polling-app/internal/apperror"
+	"polling-app/internal/models"
+	"polling-app/pkg/database"
+)
+
+// Permission is an action a room member may be allowed to take
+type Permission int
+
+const (
+	// View lets a member open the room, its WebSocket and its results
+	View Permission = iota
+	// Participate lets a member vote, ask questions and upvote
+	Participate
+	// Moderate lets a member approve or hide answers and questions
+	Moderate
+	// ManagePolls lets a member create and run polls and see individual votes
+	ManagePolls
+	// ManageRoles lets a member grant and revoke roles below their own
+	ManageRoles
+	// ManageInvites lets a member issue, limit, rotate and revoke invite links
+	ManageInvites
+	// ManageJoinPolicy lets a member choose who may join the room
+	ManageJoinPolicy
+	// CloseRoom lets a member close and reopen the room
+	CloseRoom
+	// TransferOwnership lets a member hand the room to someone else
+	TransferOwnership
+	// DeleteRoom lets a member archive or delete the room
+	DeleteRoom
+)
+
+// minimumRoles is the least privileged role that holds each permission
+var minimumRoles = map[Permission]string{
+	View:              models.RoleViewer,
+	Participate:       models.RoleParticipant,
+	Moderate:          models.RoleModerator,
+	ManagePolls:       models.RoleCoHost,
+	ManageRoles:       models.RoleCoHost,
+	ManageInvites:     models.RoleCoHost,
+	ManageJoinPolicy:  models.RoleCoHost,
+	CloseRoom:         models.RoleCoHost,
+	TransferOwnership: models.RoleOwner,
+	DeleteRoom:        models.RoleOwner,
+}
+
+// descriptions complete the error message for a missing permission
+var descriptions = map[Permission]string{
+	View:              "view this room",
+	Participate:       "take part in this room",
+	Moderate:          "moderate this room",
+	ManagePolls:       "manage polls in this room",
+	ManageRoles:       "manage roles in this room",
+	ManageInvites:     "manage invites to this room",
+	ManageJoinPolicy:  "change who may join this room",
+	CloseRoom:         "close this room",
+	TransferOwnership: "transfer this room",
+	DeleteRoom:        "delete this room",
+}
+
+// needsOpenRoom lists the permissions that change a room's content, which
+// closed and archived rooms no longer accept
+var needsOpenRoom = map[Permission]bool{
+	Participate: true,
+	Moderate:    true,
+	ManagePolls: true,
+}
+
+// liveMembers scopes a query on room_participants to rooms that have not been
+// deleted. A deleted room keeps its members until it is purged, but they lose
+// every permission in it straight away.
+func liveMembers() *gorm.DB {
+	return database.DB.Model(&models.RoomParticipant{}).
+		Joins("JOIN rooms ON rooms.id = room_participants.room_id AND rooms.deleted_at IS NULL")
+}
+
+// MemberOf returns the user's membership of the room, or nil if they are not
+// a member or the room has been deleted
+func MemberOf(roomID string, userID uint) (*models.RoomParticipant, error) {
+	var member models.RoomParticipant
+	err := liveMembers().
+		Where("room_participants.room_id = ? AND room_participants.user_id = ?", roomID, userID).
+		First(&member).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// RoleOf returns the user's role in the room, or "" if they are not a member
+func RoleOf(roomID string, userID uint) (string, error) {
+	member, err := MemberOf(roomID, userID)
+	if member == nil {
+		return "", err
+	}
+	return member.Role, nil
+}
+
+// Allows reports whether a role holds a permission
+func Allows(role string, perm Permission) bool {
+	return models.RoleRank(role) > 0 && models.RoleRank(role) >= models.RoleRank(minimumRoles[perm])
+}
+
+// MembersWith returns the IDs of the room's members whose role holds a
+// permission
+func MembersWith(roomID string, perm Permission) ([]uint, error) {
+	var roles []string
+	for _, role := range []string{models.RoleOwner, models.RoleCoHost, models.RoleModerator, models.RoleParticipant, models.RoleViewer} {
+		if Allows(role, perm) {
+			roles = append(roles, role)
+		}
+	}
+
+	var userIDs []uint
+	err := liveMembers().
+		Where("room_participants.room_id = ? AND room_participants.role IN ?", roomID, roles).
+		Pluck("room_participants.user_id", &userIDs).Error
+	return userIDs, err
+}
+
+// Can reports whether the user's role holds a permission in the room. It
+// answers what the user may see, so unlike Require it ignores whether the room
+// is open and whether the user is muted.
+func Can(roomID string, userID uint, perm Permission) bool {
+	member, err := MemberOf(roomID, userID)
+	return err == nil && member != nil && Allows(member.Role, perm)
+}
+
+// Require checks that the user may act with a permission in the room.
+// Non-members, members without the permission and muted members trying to
+// participate get a 403; actions that change a room that is not open get a 400.
+func Require(roomID string, userID uint, perm Permission) error {
+	if needsOpenRoom[perm] {
+		if err := RequireOpen(roomID); err != nil {
+			return err
+		}
+	}
+
+	member, err := MemberOf(roomID, userID)
+	if err != nil {
+		return apperror.New(http.StatusInternalServerError, "Failed to check permissions")
+	}
+	if member == nil {
+		return apperror.New(http.StatusForbidden, "Not a participant in this room")
+	}
+	if !Allows(member.Role, perm) {
+		return apperror.New(http.StatusForbidden, "You are not allowed to "+descriptions[perm])
+	}
+	if perm == Participate && member.Muted {
+		return apperror.New(http.StatusForbidden, "You are muted in this room")
+	}
+	return nil
+}
+
+// RequireOpen checks that the room exists and is open
+func RequireOpen(roomID string) error {
+	var room models.Room
+	if err := database.DB.Select("id", "status").First(&room, "id = ?", roomID).Error; err != nil {
+		return apperror.New(http.StatusNotFound, "Room not found")
+	}
+	if room.Status != models.RoomStatusOpen {
+		return apperror.New(http.StatusBadRequest, "Room is "+room.Status)
+	}
+	return nil
+}
+
+// RequireOutrank checks that the actor holds a permission and ranks above the
+// target member, and returns the target's membership
+func RequireOutrank(roomID string, actorID, targetID uint, perm Permission) (*models.RoomParticipant, error) {
+	if err := Require(roomID, actorID, perm); err != nil {
+		return nil, err
+	}
+	if actorID == targetID {
+		return nil, apperror.New(http.StatusBadRequest, "You cannot do this to yourself")
+	}
+
+	actor, err := MemberOf(roomID, actorID)
+	if err != nil {
+		return nil, apperror.New(http.StatusInternalServerError, "Failed to check permissions")
+	}
+	target, err := MemberOf(roomID, targetID)
+	if err != nil {
+		return nil, apperror.New(http.StatusInternalServerError, "Failed to check permissions")
+	}
+	if target == nil {
+		return nil, apperror.New(http.StatusNotFound, "Member not found")
+	}
+	if models.RoleRank(target.Role) >= models.RoleRank(actor.Role) {
+		return nil, apperror.New(http.StatusForbidden, "You can only act on members ranked below you")
+	}
+	return target, nil
+}
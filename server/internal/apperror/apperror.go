@@ -0,0 +1,45 @@
+package apperror
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Error is returned by services shared between REST handlers and WebSocket
+// commands. REST handlers respond with Status; WebSocket commands only send
+// Message back to the client.
+type Error struct {
+	Status  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// New returns an Error with the given HTTP status and client-facing message
+func New(status int, message string) error {
+	return &Error{Status: status, Message: message}
+}
+
+// StatusOf returns the HTTP status carried by err, or 500 for other errors
+func StatusOf(err error) int {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Status
+	}
+	return http.StatusInternalServerError
+}
+
+// Respond writes err as a JSON error response. Errors that are not an Error
+// are reported as a generic internal error so details do not leak.
+func Respond(c *gin.Context, err error) {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		c.JSON(appErr.Status, gin.H{"error": appErr.Message})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"polling-app/internal/models"
@@ -58,14 +59,22 @@ func GoogleCallback(c *gin.Context) {
 	if result.Error != nil {
 		// Create new user
 		user = models.User{
-			Email:    userInfo.Email,
-			Name:     userInfo.Name,
-			GoogleID: userInfo.ID,
+			Email:         userInfo.Email,
+			EmailVerified: userInfo.VerifiedEmail,
+			Name:          userInfo.Name,
+			GoogleID:      userInfo.ID,
 		}
 		if err := database.DB.Create(&user).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
 			return
 		}
+	} else if userInfo.VerifiedEmail && !user.EmailVerified {
+		// Google has confirmed the address of an existing account
+		user.EmailVerified = true
+		if err := database.DB.Model(&user).Update("email_verified", true).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+			return
+		}
 	}
 
 	// Generate JWT token
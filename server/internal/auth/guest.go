@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"polling-app/internal/models"
+	"polling-app/pkg/database"
+)
+
+// defaultGuestTokenTTL is used when GUEST_TOKEN_EXPIRATION is unset or invalid
+const defaultGuestTokenTTL = 4 * time.Hour
+
+type UpgradeGuestRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=6"`
+	Name     string `json:"name"`
+}
+
+// GenerateGuestToken issues a short-lived token for a guest participant
+func GenerateGuestToken(user models.User) (string, time.Time, error) {
+	ttl := defaultGuestTokenTTL
+	if value := os.Getenv("GUEST_TOKEN_EXPIRATION"); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed > 0 {
+			ttl = parsed
+		}
+	}
+	expiresAt := time.Now().Add(ttl)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": user.ID,
+		"guest":   true,
+		"exp":     expiresAt.Unix(),
+	})
+
+	signed, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	return signed, expiresAt, err
+}
+
+// UpgradeGuest turns the current guest into a registered account. The user
+// record is updated in place, so votes cast as a guest are kept.
+func UpgradeGuest(c *gin.Context) {
+	var req UpgradeGuestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+	currentUser := user.(models.User)
+
+	if !currentUser.IsGuest {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Account is already registered"})
+		return
+	}
+
+	var existingUser models.User
+	if err := database.DB.Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "User already exists"})
+		return
+	}
+
+	currentUser.Email = req.Email
+	currentUser.IsGuest = false
+	if req.Name != "" {
+		currentUser.Name = req.Name
+	}
+	if err := currentUser.SetPassword(req.Password); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
+	}
+
+	if err := database.DB.Save(&currentUser).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upgrade account"})
+		return
+	}
+
+	token, err := generateToken(currentUser)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, AuthResponse{
+		Token: token,
+		User:  currentUser,
+	})
+}
@@ -1,7 +1,9 @@
 package auth
 
 import (
+	"errors"
 	"net/http"
+	"os"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -27,28 +29,16 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		tokenString := parts[1]
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			return []byte(os.Getenv("JWT_SECRET")), nil
-		})
-
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		user, err := userFromToken(parts[1])
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			c.Abort()
 			return
 		}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
-			c.Abort()
-			return
-		}
-
-		userID := uint(claims["user_id"].(float64))
-		var user models.User
-		if err := database.DB.First(&user, userID).Error; err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		// Guests may only use the routes mounted behind OptionalAuthMiddleware
+		if user.IsGuest {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Guest accounts cannot access this resource"})
 			c.Abort()
 			return
 		}
@@ -74,25 +64,8 @@ func OptionalAuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		tokenString := parts[1]
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			return []byte(os.Getenv("JWT_SECRET")), nil
-		})
-
-		if err != nil || !token.Valid {
-			c.Next()
-			return
-		}
-
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.Next()
-			return
-		}
-
-		userID := uint(claims["user_id"].(float64))
-		var user models.User
-		if err := database.DB.First(&user, userID).Error; err != nil {
+		user, err := userFromToken(parts[1])
+		if err != nil {
 			c.Next()
 			return
 		}
@@ -100,4 +73,39 @@ func OptionalAuthMiddleware() gin.HandlerFunc {
 		c.Set("user", user)
 		c.Next()
 	}
-} 
\ No newline at end of file
+}
+
+// userFromToken validates a signed token and loads the user it was issued to.
+// Guest tokens are only honoured while the user record is still a guest, so a
+// token issued before an upgrade cannot be replayed after it.
+func userFromToken(tokenString string) (models.User, error) {
+	var user models.User
+
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		return []byte(os.Getenv("JWT_SECRET")), nil
+	})
+	if err != nil || !token.Valid {
+		return user, errors.New("Invalid token")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return user, errors.New("Invalid token claims")
+	}
+
+	rawID, ok := claims["user_id"].(float64)
+	if !ok {
+		return user, errors.New("Invalid token claims")
+	}
+
+	if err := database.DB.First(&user, uint(rawID)).Error; err != nil {
+		return user, errors.New("User not found")
+	}
+
+	isGuestToken, _ := claims["guest"].(bool)
+	if isGuestToken != user.IsGuest {
+		return user, errors.New("Invalid token")
+	}
+
+	return user, nil
+}
@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"polling-app/internal/access"
+	"polling-app/internal/apperror"
+	"polling-app/internal/models"
+	"polling-app/pkg/database"
+)
+
+const (
+	// defaultTicketTTL is used when WS_TICKET_EXPIRATION is unset or invalid
+	defaultTicketTTL = 30 * time.Second
+
+	// ticketProtocolPrefix marks the Sec-WebSocket-Protocol entry carrying a ticket
+	ticketProtocolPrefix = "ticket."
+
+	// WebSocketProtocol is the subprotocol the server selects for room sockets
+	WebSocketProtocol = "polling"
+)
+
+var errInvalidTicket = errors.New("invalid ticket")
+
+type TicketResponse struct {
+	Ticket    string    `json:"ticket"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Protocol  string    `json:"protocol"`
+}
+
+// IssueWebSocketTicket creates a single-use ticket that lets the current user
+// open the WebSocket of the given room. Browsers cannot send an Authorization
+// header during the upgrade, so the ticket is passed instead of the JWT.
+func IssueWebSocketTicket(c *gin.Context) {
+	roomID := c.Param("id")
+
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+	currentUser := user.(models.User)
+
+	var room models.Room
+	if err := database.DB.First(&room, "id = ?", roomID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Room not found"})
+		return
+	}
+
+	if err := access.Require(room.ID, currentUser.ID, access.View); err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	if room.Status != models.RoomStatusOpen {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Room is " + room.Status})
+		return
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate ticket"})
+		return
+	}
+	ticket := hex.EncodeToString(raw)
+
+	record := models.WebSocketTicket{
+		TokenHash: hashTicket(ticket),
+		UserID:    currentUser.ID,
+		RoomID:    room.ID,
+		ExpiresAt: time.Now().Add(ticketTTL()),
+	}
+	if err := database.DB.Create(&record).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create ticket"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, TicketResponse{
+		Ticket:    ticket,
+		ExpiresAt: record.ExpiresAt,
+		Protocol:  WebSocketProtocol,
+	})
+}
+
+// WebSocketAuthMiddleware authenticates a WebSocket upgrade. A ticket is read
+// from the "ticket" query parameter or from a "ticket.<value>" entry in the
+// Sec-WebSocket-Protocol header and redeemed for the room in the URL. Clients
+// that can set headers may still send a Bearer token instead.
+func WebSocketAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ticket := c.Query("ticket")
+		if ticket == "" {
+			ticket = ticketFromProtocols(c.Request)
+		}
+
+		if ticket == "" {
+			OptionalAuthMiddleware()(c)
+			return
+		}
+
+		user, err := redeemTicket(ticket, c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired ticket"})
+			c.Abort()
+			return
+		}
+
+		c.Set("user", user)
+		c.Next()
+	}
+}
+
+// redeemTicket marks the ticket as used and returns its user. The conditional
+// update makes redemption single-use even under concurrent upgrades.
+func redeemTicket(ticket, roomID string) (models.User, error) {
+	var user models.User
+	now := time.Now()
+
+	result := database.DB.Model(&models.WebSocketTicket{}).
+		Where("token_hash = ? AND room_id = ? AND used_at IS NULL AND expires_at > ?", hashTicket(ticket), roomID, now).
+		Update("used_at", now)
+	if result.Error != nil {
+		return user, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return user, errInvalidTicket
+	}
+
+	var record models.WebSocketTicket
+	if err := database.DB.First(&record, "token_hash = ?", hashTicket(ticket)).Error; err != nil {
+		return user, err
+	}
+
+	if err := database.DB.First(&user, record.UserID).Error; err != nil {
+		return user, err
+	}
+
+	return user, nil
+}
+
+func ticketFromProtocols(r *http.Request) string {
+	for _, header := range r.Header.Values("Sec-WebSocket-Protocol") {
+		for _, protocol := range strings.Split(header, ",") {
+			protocol = strings.TrimSpace(protocol)
+			if strings.HasPrefix(protocol, ticketProtocolPrefix) {
+				return strings.TrimPrefix(protocol, ticketProtocolPrefix)
+			}
+		}
+	}
+	return ""
+}
+
+func hashTicket(ticket string) string {
+	sum := sha256.Sum256([]byte(ticket))
+	return hex.EncodeToString(sum[:])
+}
+
+func ticketTTL() time.Duration {
+	if value := os.Getenv("WS_TICKET_EXPIRATION"); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed > 0 {
+			return parsed
+		}
+	}
+	return defaultTicketTTL
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// inviteAlphabet leaves out characters that are easily confused when a
+	// code is read aloud or typed: 0/O, 1/I/L
+	inviteAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+	// inviteCodeLength gives 31^8, about 8.5e11, possible codes
+	inviteCodeLength = 8
+
+	// inviteCodeAttempts bounds the retries after a collision
+	inviteCodeAttempts = 5
+
+	// DefaultInviteName names the invite link every room is created with
+	DefaultInviteName = "Default"
+)
+
+// RoomInvite is an invite link to a room. Every room has one default invite,
+// whose code is mirrored in Room.InviteCode; hosts may add named links to see
+// which channel participants came from. MaxUses of 0 means unlimited.
+type RoomInvite struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	RoomID      string     `json:"room_id" gorm:"not null;index"`
+	Code        string     `json:"code" gorm:"not null;uniqueIndex"`
+	Name        string     `json:"name" gorm:"not null"`
+	IsDefault   bool       `json:"is_default" gorm:"default:false"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	MaxUses     int        `json:"max_uses" gorm:"default:0"`
+	Uses        int        `json:"uses" gorm:"default:0"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+	CreatedByID uint       `json:"created_by_id" gorm:"not null"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// GenerateInviteCode returns a random invite code that no invite uses yet. It
+// is safe to call from hooks running on tx.
+func GenerateInviteCode(tx *gorm.DB) (string, error) {
+	for attempt := 0; attempt < inviteCodeAttempts; attempt++ {
+		code, err := randomInviteCode()
+		if err != nil {
+			return "", err
+		}
+
+		var count int64
+		err = tx.Session(&gorm.Session{NewDB: true}).Model(&RoomInvite{}).Where("code = ?", code).Count(&count).Error
+		if err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return code, nil
+		}
+	}
+	return "", errors.New("could not generate a unique invite code")
+}
+
+func randomInviteCode() (string, error) {
+	max := big.NewInt(int64(len(inviteAlphabet)))
+	code := make([]byte, inviteCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = inviteAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Join policies decide who may join a room with a valid invite
+const (
+	JoinPolicyOpen     = "open"     // Anyone with an invite joins at once
+	JoinPolicyPassword = "password" // Joiners must also give the room password
+	JoinPolicyDomain   = "domain"   // Only accounts whose email is in AllowedDomains
+	JoinPolicyApproval = "approval" // Joiners wait in the lobby until a moderator admits them
+)
+
+// Join request statuses. Pending requests wait for a moderator; waitlisted
+// ones wait for a free seat and are admitted in the order they arrived.
+const (
+	JoinRequestPending    = "pending"
+	JoinRequestWaitlisted = "waitlisted"
+	JoinRequestApproved   = "approved"
+	JoinRequestDenied     = "denied"
+)
+
+// JoinRequest is a user waiting in the lobby of a room, either for approval
+// or for a seat. A user has at most one queued request per room.
+type JoinRequest struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	RoomID      string     `json:"room_id" gorm:"not null;uniqueIndex:idx_join_requests_queued,where:status IN ('pending','waitlisted')"`
+	UserID      uint       `json:"user_id" gorm:"not null;uniqueIndex:idx_join_requests_queued,where:status IN ('pending','waitlisted')"`
+	InviteID    *uint      `json:"-"` // Invite link used; carried over to the membership
+	Status      string     `json:"status" gorm:"not null;default:pending"`
+	DecidedByID *uint      `json:"decided_by_id"`
+	DecidedAt   *time.Time `json:"decided_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	Position    int        `json:"position,omitempty" gorm:"-"` // Place on the waitlist, counting from 1
+}
+
+// SetJoinPassword hashes the room password and stores it
+func (r *Room) SetJoinPassword(password string) error {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	r.JoinPassword = string(hashed)
+	return nil
+}
+
+// CheckJoinPassword verifies a password against the room's hash
+func (r *Room) CheckJoinPassword(password string) bool {
+	return r.JoinPassword != "" && bcrypt.CompareHashAndPassword([]byte(r.JoinPassword), []byte(password)) == nil
+}
+
+// AllowsEmail reports whether the email's domain is in the room's allow-list
+func (r *Room) AllowsEmail(email string) bool {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	domain := strings.ToLower(email[at+1:])
+	for _, allowed := range strings.Split(r.AllowedDomains, ",") {
+		if allowed != "" && allowed == domain {
+			return true
+		}
+	}
+	return false
+}
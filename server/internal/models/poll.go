@@ -4,18 +4,76 @@ import (
 	"time"
 )
 
+// Poll lifecycle states. Polls move draft -> (scheduled ->) live, may be
+// paused and resumed while live, and end closed; a closed poll can then be
+// revealed to show its correct answers.
+const (
+	PollStatusDraft     = "draft"
+	PollStatusScheduled = "scheduled"
+	PollStatusLive      = "live"
+	PollStatusPaused    = "paused"
+	PollStatusClosed    = "closed"
+	PollStatusRevealed  = "revealed"
+)
+
+// Poll modes. Quiz polls score correct answers by speed.
+const (
+	PollModePoll = "poll"
+	PollModeQuiz = "quiz"
+)
+
+// Poll types. Single, multiple choice and ranked polls are answered by
+// selecting options; MinChoices and MaxChoices bound how many a multiple
+// choice or ranked ballot may select. Text polls take a short free-text
+// answer and numeric polls a number between MinValue and MaxValue instead.
+const (
+	PollTypeSingle   = "single"
+	PollTypeMultiple = "multiple"
+	PollTypeRanked   = "ranked"
+	PollTypeText     = "text"
+	PollTypeNumeric  = "numeric"
+)
+
+// Moderation states of a free-text answer. Answers to moderated polls start
+// pending and are only shown to the room once a host approves them.
+const (
+	ModerationPending  = "pending"
+	ModerationApproved = "approved"
+	ModerationHidden   = "hidden"
+)
+
 type Poll struct {
-	ID          uint      `json:"id" gorm:"primaryKey"`
-	RoomID      string    `json:"room_id" gorm:"not null"`
-	Room        Room      `json:"room" gorm:"foreignKey:RoomID"`
-	Question    string    `json:"question" gorm:"not null"`
-	Options     []Option  `json:"options" gorm:"foreignKey:PollID"`
-	Duration    int       `json:"duration" gorm:"not null"` // Duration in seconds
-	StartTime   time.Time `json:"start_time"`
-	EndTime     time.Time `json:"end_time"`
-	IsActive    bool      `json:"is_active" gorm:"default:false"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	RoomID       string     `json:"room_id" gorm:"not null"`
+	Room         Room       `json:"room" gorm:"foreignKey:RoomID"`
+	Question     string     `json:"question" gorm:"not null"`
+	Options      []Option   `json:"options" gorm:"foreignKey:PollID"`
+	Duration     int        `json:"duration" gorm:"not null"` // Duration in seconds
+	Type         string     `json:"type" gorm:"not null;default:single"`
+	MinChoices   int        `json:"min_choices" gorm:"default:1"`
+	MaxChoices   int        `json:"max_choices" gorm:"default:1"`
+	Moderated    bool       `json:"moderated" gorm:"default:false"`    // Text polls: answers need host approval
+	Anonymous    bool       `json:"anonymous" gorm:"default:false"`    // Ballots are stored without a link to the voter
+	AllowRevote  bool       `json:"allow_revote" gorm:"default:false"` // Voters may change their ballot while the poll is live
+	MinValue     float64    `json:"min_value" gorm:"default:0"`        // Numeric polls: answer range and step
+	MaxValue     float64    `json:"max_value" gorm:"default:0"`
+	Step         float64    `json:"step" gorm:"default:0"`
+	CorrectValue *float64   `json:"correct_value,omitempty"` // Numeric polls: answers within Tolerance of it are correct
+	Tolerance    float64    `json:"tolerance" gorm:"default:0"`
+	Mode         string     `json:"mode" gorm:"not null;default:poll"`
+	MaxPoints    int        `json:"max_points" gorm:"default:0"` // Points for an instant correct answer (quiz only)
+	Decay        float64    `json:"decay" gorm:"default:0"`      // Share of MaxPoints lost by answering at the deadline (quiz only)
+	Status       string     `json:"status" gorm:"not null;default:draft;index"`
+	ScheduledAt  *time.Time `json:"scheduled_at"`
+	StartTime    time.Time  `json:"start_time"`
+	EndTime      time.Time  `json:"end_time" gorm:"index"`
+	PausedAt     *time.Time `json:"paused_at"`
+	PausedTotal  float64    `json:"paused_total" gorm:"default:0"` // Seconds spent paused so far
+	ClosedAt     *time.Time `json:"closed_at"`
+	RevealedAt   *time.Time `json:"revealed_at"`
+	IsActive     bool       `json:"is_active" gorm:"default:false"` // True only while live
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
 }
 
 type Option struct {
@@ -23,43 +81,123 @@ type Option struct {
 	PollID    uint      `json:"poll_id" gorm:"not null"`
 	Text      string    `json:"text" gorm:"not null"`
 	IsCorrect bool      `json:"is_correct" gorm:"default:false"`
-	Votes     []Vote    `json:"votes" gorm:"foreignKey:OptionID"`
+	Votes     []Vote    `json:"-" gorm:"foreignKey:OptionID"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+// Vote is one voter's ballot on a poll. The unique index on (poll, voter)
+// allows one ballot per person; anonymous ballots have no voter and are
+// limited by VoteReceipt instead.
 type Vote struct {
+	ID              uint           `json:"id" gorm:"primaryKey"`
+	UserID          *uint          `json:"user_id,omitempty" gorm:"uniqueIndex:idx_votes_poll_voter"` // Nil on anonymous polls
+	User            *User          `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	PollID          uint           `json:"poll_id" gorm:"not null;uniqueIndex:idx_votes_poll_voter"`
+	Poll            Poll           `json:"poll" gorm:"foreignKey:PollID"`
+	OptionID        *uint          `json:"option_id,omitempty"` // Set for single choice polls
+	Option          *Option        `json:"option,omitempty" gorm:"foreignKey:OptionID"`
+	Selections      []Selection    `json:"selections,omitempty" gorm:"foreignKey:VoteID"`
+	Text            string         `json:"text,omitempty"`                     // Free-text answer (text polls)
+	Moderation      string         `json:"moderation,omitempty"`               // Moderation state of a free-text answer
+	Value           *float64       `json:"value,omitempty"`                    // Numeric answer (numeric polls)
+	TimeTaken       float64        `json:"time_taken" gorm:"not null"`         // Time taken to answer in seconds, as used for scoring
+	ServerTimeTaken float64        `json:"server_time_taken" gorm:"default:0"` // Measured from start_poll reaching the participant, kept for auditing
+	ClientTimeTaken float64        `json:"client_time_taken" gorm:"default:0"` // Time reported by the client, kept for auditing
+	Correct         bool           `json:"correct" gorm:"default:false"`
+	Points          int            `json:"points" gorm:"default:0"` // Score earned in quiz mode
+	Revisions       []VoteRevision `json:"revisions,omitempty" gorm:"foreignKey:VoteID"`
+	RevisedAt       *time.Time     `json:"revised_at,omitempty"` // When the ballot was last changed
+	CreatedAt       time.Time      `json:"created_at"`
+}
+
+// Selection is one option chosen on a ballot. Every option-based vote has at
+// least one; PollID is denormalised so results can be counted per poll. On
+// ranked ballots Rank orders the selections, starting at 1 for the first
+// preference.
+type Selection struct {
+	ID       uint `json:"id" gorm:"primaryKey"`
+	VoteID   uint `json:"vote_id" gorm:"not null;index"`
+	PollID   uint `json:"poll_id" gorm:"not null;index"`
+	OptionID uint `json:"option_id" gorm:"not null"`
+	Rank     int  `json:"rank,omitempty" gorm:"default:0"`
+}
+
+// VoteRevision keeps a ballot as it stood before its voter changed it.
+// OptionIDs lists the chosen options in ballot order, comma separated.
+type VoteRevision struct {
 	ID        uint      `json:"id" gorm:"primaryKey"`
-	UserID    uint      `json:"user_id" gorm:"not null"`
-	User      User      `json:"user" gorm:"foreignKey:UserID"`
-	PollID    uint      `json:"poll_id" gorm:"not null"`
-	Poll      Poll      `json:"poll" gorm:"foreignKey:PollID"`
-	OptionID  uint      `json:"option_id" gorm:"not null"`
-	Option    Option    `json:"option" gorm:"foreignKey:OptionID"`
-	TimeTaken float64   `json:"time_taken" gorm:"not null"` // Time taken to answer in seconds
-	CreatedAt time.Time `json:"created_at"`
+	VoteID    uint      `json:"vote_id" gorm:"not null;index"`
+	PollID    uint      `json:"poll_id" gorm:"not null;index"`
+	OptionIDs string    `json:"option_ids,omitempty"`
+	Text      string    `json:"text,omitempty"`
+	Value     *float64  `json:"value,omitempty"`
+	CastAt    time.Time `json:"cast_at"`    // When the replaced ballot was cast
+	CreatedAt time.Time `json:"created_at"` // When it was replaced
+}
+
+// VoteReceipt records that a user voted on an anonymous poll, so each person
+// votes once without their ballot pointing back to them. It deliberately has
+// no ID or timestamp that could be matched against the ballots.
+type VoteReceipt struct {
+	PollID uint `json:"poll_id" gorm:"primaryKey;autoIncrement:false"`
+	UserID uint `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
 }
 
 // StartPoll activates the poll and sets the start and end times
 func (p *Poll) StartPoll() {
+	p.Status = PollStatusLive
 	p.IsActive = true
+	p.ScheduledAt = nil
 	p.StartTime = time.Now()
 	p.EndTime = p.StartTime.Add(time.Duration(p.Duration) * time.Second)
 }
 
+// Pause freezes the poll's clock; the remaining time is kept until Resume
+func (p *Poll) Pause() {
+	now := time.Now()
+	p.Status = PollStatusPaused
+	p.IsActive = false
+	p.PausedAt = &now
+}
+
+// Resume restarts a paused poll, pushing EndTime back by the time spent paused
+func (p *Poll) Resume() {
+	if p.PausedAt != nil {
+		paused := time.Since(*p.PausedAt)
+		p.EndTime = p.EndTime.Add(paused)
+		p.PausedTotal += paused.Seconds()
+	}
+	p.Status = PollStatusLive
+	p.IsActive = true
+	p.PausedAt = nil
+}
+
+// Extend adds time to a live or paused poll
+func (p *Poll) Extend(seconds int) {
+	p.Duration += seconds
+	p.EndTime = p.EndTime.Add(time.Duration(seconds) * time.Second)
+}
+
 // IsExpired checks if the poll has ended
 func (p *Poll) IsExpired() bool {
+	if p.Status == PollStatusPaused {
+		return false
+	}
 	return time.Now().After(p.EndTime)
 }
 
 // GetTimeRemaining returns the remaining time in seconds
 func (p *Poll) GetTimeRemaining() float64 {
-	if !p.IsActive {
-		return 0
+	var remaining float64
+	switch {
+	case p.Status == PollStatusPaused && p.PausedAt != nil:
+		remaining = p.EndTime.Sub(*p.PausedAt).Seconds()
+	case p.IsActive:
+		remaining = p.EndTime.Sub(time.Now()).Seconds()
 	}
-	remaining := p.EndTime.Sub(time.Now()).Seconds()
 	if remaining < 0 {
 		return 0
 	}
 	return remaining
-} 
\ No newline at end of file
+}
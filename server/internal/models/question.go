@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+)
+
+// Question is an audience question on a room's Q&A board. AuthorID is kept
+// for anonymous questions too, but is never shown to the room.
+type Question struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	RoomID    string    `json:"room_id" gorm:"not null;index"`
+	AuthorID  uint      `json:"-" gorm:"not null"`
+	Author    User      `json:"-" gorm:"foreignKey:AuthorID"`
+	Anonymous bool      `json:"anonymous" gorm:"default:false"`
+	Text      string    `json:"text" gorm:"not null"`
+	Upvotes   int       `json:"upvotes" gorm:"default:0"`
+	Pinned    bool      `json:"pinned" gorm:"default:false"`
+	Answered  bool      `json:"answered" gorm:"default:false"`
+	Hidden    bool      `json:"hidden" gorm:"default:false"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// QuestionUpvote records one participant's upvote. The unique index allows a
+// single upvote per participant and question.
+type QuestionUpvote struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	QuestionID uint      `json:"question_id" gorm:"not null;uniqueIndex:idx_question_upvotes_voter"`
+	UserID     uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_question_upvotes_voter"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+)
+
+// Room roles, from most to least privileged. Every room has exactly one
+// owner, who is also the room's HostID.
+const (
+	RoleOwner       = "owner"
+	RoleCoHost      = "co_host"
+	RoleModerator   = "moderator"
+	RoleParticipant = "participant"
+	RoleViewer      = "viewer"
+)
+
+// roleRanks orders the roles; a higher rank holds every permission of a lower one
+var roleRanks = map[string]int{
+	RoleViewer:      1,
+	RoleParticipant: 2,
+	RoleModerator:   3,
+	RoleCoHost:      4,
+	RoleOwner:       5,
+}
+
+// AudienceRoles are the roles that take up a seat in a room with a capacity
+var AudienceRoles = []string{RoleParticipant, RoleViewer}
+
+// RoleRank returns the rank of a role, or 0 for an unknown role
+func RoleRank(role string) int {
+	return roleRanks[role]
+}
+
+// IsStaff reports whether a role runs the room rather than attending it.
+// Staff never count against the room's capacity.
+func IsStaff(role string) bool {
+	return RoleRank(role) >= RoleRank(RoleModerator)
+}
+
+// RoomParticipant is the join table behind Room.Participants. Each row makes
+// a user a member of the room with a role.
+type RoomParticipant struct {
+	RoomID    string    `json:"room_id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"primaryKey"`
+	Role      string    `json:"role" gorm:"not null;default:participant"`
+	Muted     bool      `json:"muted" gorm:"default:false"` // Muted members may watch but not vote or post
+	InviteID  *uint     `json:"invite_id,omitempty"`        // Invite link the member joined through
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// RoomBan keeps a user out of a room. Guests are also matched by the
+// fingerprints recorded when they joined, so a new guest account on the same
+// device is refused too; guests who send no device ID are matched on their
+// address and browser instead.
+type RoomBan struct {
+	ID                 uint      `json:"id" gorm:"primaryKey"`
+	RoomID             string    `json:"room_id" gorm:"not null;index"`
+	UserID             uint      `json:"user_id" gorm:"not null;index"`
+	Fingerprint        string    `json:"-" gorm:"index"`
+	NetworkFingerprint string    `json:"-" gorm:"index"`
+	Reason             string    `json:"reason"`
+	BannedByID         uint      `json:"banned_by_id" gorm:"not null"`
+	CreatedAt          time.Time `json:"created_at"`
+}
@@ -4,30 +4,56 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Room statuses. Only open rooms take joins, votes and new polls; closed
+// rooms can be reopened, archived rooms keep their results read-only for good.
+const (
+	RoomStatusOpen     = "open"
+	RoomStatusClosed   = "closed"
+	RoomStatusArchived = "archived"
 )
 
 type Room struct {
-	ID          string    `json:"id" gorm:"primaryKey"`
-	Name        string    `json:"name" gorm:"not null"`
-	HostID      uint      `json:"host_id" gorm:"not null"`
-	Host        User      `json:"host" gorm:"foreignKey:HostID"`
-	InviteCode  string    `json:"invite_code" gorm:"unique;not null"`
-	IsActive    bool      `json:"is_active" gorm:"default:true"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	Participants []User   `json:"participants" gorm:"many2many:room_participants;"`
+	ID              string         `json:"id" gorm:"primaryKey"`
+	Name            string         `json:"name" gorm:"not null"`
+	HostID          uint           `json:"host_id" gorm:"not null"`
+	Host            User           `json:"host" gorm:"foreignKey:HostID"`
+	InviteCode      string         `json:"invite_code" gorm:"unique;not null"`
+	JoinPolicy      string         `json:"join_policy" gorm:"not null;default:open"`
+	JoinPassword    string         `json:"-"`                                 // Bcrypt hash, used by the password policy
+	AllowedDomains  string         `json:"allowed_domains"`                   // Comma-separated, lower case, used by the domain policy
+	MaxParticipants int            `json:"max_participants" gorm:"default:0"` // Seats for the audience; 0 means unlimited
+	IsActive        bool           `json:"is_active" gorm:"default:true"`     // True only while open
+	Status          string         `json:"status" gorm:"not null;default:open"`
+	ClosedAt        *time.Time     `json:"closed_at"`
+	ArchivedAt      *time.Time     `json:"archived_at"`
+	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"` // Set while a deleted room waits to be purged
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	Participants    []User         `json:"participants" gorm:"many2many:room_participants;"`
 }
 
-// BeforeCreate generates a unique invite code before creating the room
-func (r *Room) BeforeCreate() error {
+// BeforeCreate assigns the room's ID and invite code before it is inserted
+func (r *Room) BeforeCreate(tx *gorm.DB) error {
 	r.ID = uuid.New().String()
-	r.InviteCode = generateInviteCode()
+
+	code, err := GenerateInviteCode(tx)
+	if err != nil {
+		return err
+	}
+	r.InviteCode = code
 	return nil
 }
 
-// generateInviteCode creates a random 6-character invite code
-func generateInviteCode() string {
-	// Generate a random 6-character code using alphanumeric characters
-	code := uuid.New().String()[:6]
-	return code
-} 
\ No newline at end of file
+// AfterCreate registers the room's invite code as its default invite link
+func (r *Room) AfterCreate(tx *gorm.DB) error {
+	return tx.Session(&gorm.Session{NewDB: true}).Create(&RoomInvite{
+		RoomID:      r.ID,
+		Code:        r.InviteCode,
+		Name:        DefaultInviteName,
+		IsDefault:   true,
+		CreatedByID: r.HostID,
+	}).Error
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+)
+
+// WebSocketTicket is a single-use credential for opening a room's WebSocket.
+// Only a hash of the ticket is stored.
+type WebSocketTicket struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	TokenHash string     `json:"-" gorm:"uniqueIndex;not null"`
+	UserID    uint       `json:"user_id" gorm:"not null"`
+	RoomID    string     `json:"room_id" gorm:"not null"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -7,13 +7,17 @@ import (
 )
 
 type User struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	Email     string    `json:"email" gorm:"unique;not null"`
-	Password  string    `json:"-" gorm:"not null"`
-	Name      string    `json:"name"`
-	GoogleID  string    `json:"-" gorm:"unique"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID                 uint      `json:"id" gorm:"primaryKey"`
+	Email              string    `json:"email" gorm:"not null;uniqueIndex:idx_users_email,where:email <> ''"`
+	EmailVerified      bool      `json:"email_verified" gorm:"default:false"` // Set once a provider such as Google has confirmed the address
+	Password           string    `json:"-" gorm:"not null"`
+	Name               string    `json:"name"`
+	GoogleID           string    `json:"-" gorm:"uniqueIndex:idx_users_google_id,where:google_id <> ''"`
+	IsGuest            bool      `json:"is_guest" gorm:"default:false"`
+	Fingerprint        string    `json:"-" gorm:"index"` // Hash of the device ID a guest joined with, if they sent one
+	NetworkFingerprint string    `json:"-" gorm:"index"` // Hash of the address and user agent a guest joined from
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
 }
 
 // SetPassword hashes the password and stores it
@@ -30,4 +34,4 @@ func (u *User) SetPassword(password string) error {
 func (u *User) CheckPassword(password string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
 	return err == nil
-} 
\ No newline at end of file
+}
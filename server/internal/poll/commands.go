@@ -0,0 +1,148 @@
+package poll
+
+import (
+	"encoding/json"
+
+	"polling-app/internal/models"
+	"polling-app/internal/websocket"
+)
+
+type voteCommand struct {
+	PollID uint `json:"poll_id" binding:"required"`
+	VoteRequest
+}
+
+type pollCommand struct {
+	PollID uint `json:"poll_id" binding:"required"`
+}
+
+type scheduleCommand struct {
+	PollID uint `json:"poll_id" binding:"required"`
+	SchedulePollRequest
+}
+
+type runoffCommand struct {
+	PollID uint `json:"poll_id" binding:"required"`
+	Round  int  `json:"round" binding:"required,min=1"`
+}
+
+type moderateCommand struct {
+	PollID     uint `json:"poll_id" binding:"required"`
+	ResponseID uint `json:"response_id" binding:"required"`
+	ModerateRequest
+}
+
+type extendCommand struct {
+	PollID uint `json:"poll_id" binding:"required"`
+	ExtendPollRequest
+}
+
+// RegisterCommands installs the poll commands on the WebSocket hub
+func RegisterCommands() {
+	websocket.RegisterCommand(websocket.CommandVote, handleVoteCommand)
+	websocket.RegisterCommand(websocket.CommandStartPoll, lifecycleCommand(startPoll))
+	websocket.RegisterCommand(websocket.CommandSchedulePoll, handleScheduleCommand)
+	websocket.RegisterCommand(websocket.CommandPausePoll, lifecycleCommand(pausePoll))
+	websocket.RegisterCommand(websocket.CommandResumePoll, lifecycleCommand(resumePoll))
+	websocket.RegisterCommand(websocket.CommandExtendPoll, handleExtendCommand)
+	websocket.RegisterCommand(websocket.CommandEndPoll, lifecycleCommand(stopPoll))
+	websocket.RegisterCommand(websocket.CommandRevealPoll, lifecycleCommand(revealPoll))
+	websocket.RegisterCommand(websocket.CommandShowRunoff, handleShowRunoffCommand)
+	websocket.RegisterCommand(websocket.CommandModerate, handleModerateCommand)
+}
+
+func handleVoteCommand(client *websocket.Client, payload json.RawMessage) (interface{}, error) {
+	var cmd voteCommand
+	if err := websocket.DecodePayload(payload, &cmd); err != nil {
+		return nil, err
+	}
+
+	poll, err := loadPoll(cmd.PollID, client.RoomID)
+	if err != nil {
+		return nil, err
+	}
+
+	vote, err := castVote(poll, client.User, cmd.VoteRequest)
+	if err != nil {
+		return nil, err
+	}
+	return voterBallot(poll, vote), nil
+}
+
+// lifecycleCommand adapts a host lifecycle action to a command that takes a
+// poll ID and acks with the updated poll
+func lifecycleCommand(action func(poll *models.Poll, user models.User) error) websocket.CommandHandler {
+	return func(client *websocket.Client, payload json.RawMessage) (interface{}, error) {
+		var cmd pollCommand
+		if err := websocket.DecodePayload(payload, &cmd); err != nil {
+			return nil, err
+		}
+		return runLifecycleCommand(client, cmd.PollID, action)
+	}
+}
+
+func handleScheduleCommand(client *websocket.Client, payload json.RawMessage) (interface{}, error) {
+	var cmd scheduleCommand
+	if err := websocket.DecodePayload(payload, &cmd); err != nil {
+		return nil, err
+	}
+
+	return runLifecycleCommand(client, cmd.PollID, func(poll *models.Poll, user models.User) error {
+		return schedulePoll(poll, user, cmd.StartAt)
+	})
+}
+
+func handleExtendCommand(client *websocket.Client, payload json.RawMessage) (interface{}, error) {
+	var cmd extendCommand
+	if err := websocket.DecodePayload(payload, &cmd); err != nil {
+		return nil, err
+	}
+
+	return runLifecycleCommand(client, cmd.PollID, func(poll *models.Poll, user models.User) error {
+		return extendPoll(poll, user, cmd.Seconds)
+	})
+}
+
+func handleShowRunoffCommand(client *websocket.Client, payload json.RawMessage) (interface{}, error) {
+	var cmd runoffCommand
+	if err := websocket.DecodePayload(payload, &cmd); err != nil {
+		return nil, err
+	}
+
+	poll, err := loadPoll(cmd.PollID, client.RoomID)
+	if err != nil {
+		return nil, err
+	}
+
+	return showRunoffRound(poll, client.User, cmd.Round)
+}
+
+func handleModerateCommand(client *websocket.Client, payload json.RawMessage) (interface{}, error) {
+	var cmd moderateCommand
+	if err := websocket.DecodePayload(payload, &cmd); err != nil {
+		return nil, err
+	}
+
+	poll, err := loadPoll(cmd.PollID, client.RoomID)
+	if err != nil {
+		return nil, err
+	}
+
+	return moderateResponse(poll, client.User, cmd.ResponseID, cmd.Action)
+}
+
+func runLifecycleCommand(client *websocket.Client, pollID uint, action func(poll *models.Poll, user models.User) error) (interface{}, error) {
+	poll, err := loadPoll(pollID, client.RoomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := action(poll, client.User); err != nil {
+		return nil, err
+	}
+
+	return pollEvent{
+		Poll:          poll,
+		TimeRemaining: poll.GetTimeRemaining(),
+	}, nil
+}
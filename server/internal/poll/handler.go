@@ -5,22 +5,77 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"polling-app/internal/access"
+	"polling-app/internal/apperror"
 	"polling-app/internal/models"
-	"polling-app/internal/websocket"
 	"polling-app/pkg/database"
 )
 
 type CreatePollRequest struct {
 	RoomID    string   `json:"room_id" binding:"required"`
 	Question  string   `json:"question" binding:"required"`
-	Options   []string `json:"options" binding:"required,min=2,max=4"`
+	Options   []string `json:"options" binding:"max=10"`
 	Duration  int      `json:"duration" binding:"required,min=5,max=300"` // Duration in seconds
-	CorrectID uint     `json:"correct_id" binding:"required"`
+	CorrectID uint     `json:"correct_id"`                                // 1-based index into Options (single choice)
+
+	// Multiple choice and ranked polls let voters pick between MinChoices and
+	// MaxChoices options (default: at least one, up to all); CorrectIDs are
+	// 1-based indexes into Options
+	Type       string `json:"type" binding:"omitempty,oneof=single multiple ranked text numeric"`
+	MinChoices int    `json:"min_choices" binding:"omitempty,min=1"`
+	MaxChoices int    `json:"max_choices" binding:"omitempty,min=1"`
+	CorrectIDs []uint `json:"correct_ids"`
+
+	// Text polls collect free-text answers. Moderated (default true) holds
+	// each answer for host approval before the room sees it.
+	Moderated *bool `json:"moderated"`
+
+	// Numeric polls take a value between MinValue and MaxValue on a Step grid
+	// (default 1). Answers within Tolerance of CorrectValue count as correct.
+	MinValue     *float64 `json:"min_value"`
+	MaxValue     *float64 `json:"max_value"`
+	Step         float64  `json:"step" binding:"omitempty,gt=0"`
+	CorrectValue *float64 `json:"correct_value"`
+	Tolerance    float64  `json:"tolerance" binding:"omitempty,min=0"`
+
+	// Quiz mode scores correct answers by speed. MaxPoints and Decay default
+	// to 1000 points and a 50% loss at the deadline.
+	Mode      string   `json:"mode" binding:"omitempty,oneof=poll quiz"`
+	MaxPoints int      `json:"max_points" binding:"omitempty,min=1,max=10000"`
+	Decay     *float64 `json:"decay" binding:"omitempty,min=0,max=1"`
+
+	// Anonymous polls store ballots without a link to the voter. They cannot
+	// be quizzes, which need to credit each answer to a player.
+	Anonymous bool `json:"anonymous"`
+
+	// AllowRevote lets voters change their ballot until the poll closes. It
+	// needs identified ballots and is not available for quizzes.
+	AllowRevote bool `json:"allow_revote"`
+
+	// By default a poll goes live as soon as it is created. Draft keeps it
+	// unopened until the host starts it; StartAt schedules it instead.
+	Draft   bool       `json:"draft"`
+	StartAt *time.Time `json:"start_at"`
+}
+
+type SchedulePollRequest struct {
+	StartAt time.Time `json:"start_at" binding:"required"`
+}
+
+type RunoffRoundRequest struct {
+	Round int `json:"round" binding:"required,min=1"`
+}
+
+type ExtendPollRequest struct {
+	Seconds int `json:"seconds" binding:"required,min=1,max=600"`
 }
 
 type VoteRequest struct {
-	OptionID  uint    `json:"option_id" binding:"required"`
-	TimeTaken float64 `json:"time_taken" binding:"required"` // Time taken to answer in seconds
+	OptionID  uint     `json:"option_id"`                            // Single choice polls
+	OptionIDs []uint   `json:"option_ids"`                           // Multiple choice polls, or ranked polls in order of preference
+	Text      string   `json:"text" binding:"max=280"`               // Text polls
+	Value     *float64 `json:"value"`                                // Numeric polls
+	TimeTaken float64  `json:"time_taken" binding:"omitempty,min=0"` // Client-measured answer time in seconds; only a hint
 }
 
 func CreatePoll(c *gin.Context) {
@@ -30,6 +85,11 @@ func CreatePoll(c *gin.Context) {
 		return
 	}
 
+	if req.StartAt != nil && !req.StartAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Start time must be in the future"})
+		return
+	}
+
 	// Get user from context (set by auth middleware)
 	user, exists := c.Get("user")
 	if !exists {
@@ -38,58 +98,75 @@ func CreatePoll(c *gin.Context) {
 	}
 	currentUser := user.(models.User)
 
-	// Verify user is the host of the room
+	// Verify user may run polls in the room
 	var room models.Room
 	if err := database.DB.First(&room, "id = ?", req.RoomID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Room not found"})
 		return
 	}
 
-	if room.HostID != currentUser.ID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Only the host can create polls"})
+	if err := access.Require(room.ID, currentUser.ID, access.ManagePolls); err != nil {
+		apperror.Respond(c, err)
 		return
 	}
 
-	// Create poll
-	poll := models.Poll{
-		RoomID:   req.RoomID,
-		Question: req.Question,
-		Duration: req.Duration,
+	if req.Anonymous && req.Mode == models.PollModeQuiz {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Quizzes cannot be anonymous"})
+		return
 	}
 
-	if err := database.DB.Create(&poll).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create poll"})
+	if req.AllowRevote && (req.Anonymous || req.Mode == models.PollModeQuiz) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Revoting is not available for anonymous polls or quizzes"})
 		return
 	}
 
-	// Create options
-	for i, optionText := range req.Options {
-		option := models.Option{
-			PollID:    poll.ID,
-			Text:      optionText,
-			IsCorrect: uint(i+1) == req.CorrectID,
+	// Create poll
+	poll := models.Poll{
+		RoomID:      req.RoomID,
+		Question:    req.Question,
+		Duration:    req.Duration,
+		Anonymous:   req.Anonymous,
+		AllowRevote: req.AllowRevote,
+		Mode:        models.PollModePoll,
+		Status:      models.PollStatusDraft,
+	}
+
+	if req.Mode == models.PollModeQuiz {
+		poll.Mode = models.PollModeQuiz
+		poll.MaxPoints = defaultMaxPoints
+		if req.MaxPoints > 0 {
+			poll.MaxPoints = req.MaxPoints
 		}
-		if err := database.DB.Create(&option).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create options"})
-			return
+		poll.Decay = defaultDecay
+		if req.Decay != nil {
+			poll.Decay = *req.Decay
 		}
 	}
 
-	// Start the poll
-	poll.StartPoll()
-	if err := database.DB.Save(&poll).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start poll"})
+	options, err := configurePoll(&poll, req)
+	if err != nil {
+		apperror.Respond(c, err)
 		return
 	}
 
-	// Broadcast poll start to all clients in the room
-	websocket.BroadcastToRoom(req.RoomID, "start_poll", poll)
+	// Create the poll together with its options
+	poll.Options = options
+	if err := database.DB.Create(&poll).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create poll"})
+		return
+	}
 
-	// Set timer to end poll
-	go func() {
-		time.Sleep(time.Duration(req.Duration) * time.Second)
-		endPoll(poll.ID)
-	}()
+	// Schedule, start or leave the poll as a draft
+	switch {
+	case req.StartAt != nil:
+		err = schedulePoll(&poll, currentUser, *req.StartAt)
+	case !req.Draft:
+		err = startPoll(&poll, currentUser)
+	}
+	if err != nil {
+		apperror.Respond(c, err)
+		return
+	}
 
 	c.JSON(http.StatusCreated, poll)
 }
@@ -110,104 +187,117 @@ func Vote(c *gin.Context) {
 	}
 	currentUser := user.(models.User)
 
-	// Verify poll exists and is active
-	var poll models.Poll
-	if err := database.DB.First(&poll, "id = ?", pollID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Poll not found"})
+	poll, err := loadPoll(pollID, "")
+	if err != nil {
+		apperror.Respond(c, err)
 		return
 	}
 
-	if !poll.IsActive {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Poll is not active"})
+	vote, err := castVote(poll, currentUser, req)
+	if err != nil {
+		apperror.Respond(c, err)
 		return
 	}
 
-	// Check if user has already voted
-	var existingVote models.Vote
-	if err := database.DB.Where("poll_id = ? AND user_id = ?", pollID, currentUser.ID).First(&existingVote).Error; err == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Already voted"})
-		return
-	}
+	c.JSON(http.StatusOK, voterBallot(poll, vote))
+}
 
-	// Create vote
-	vote := models.Vote{
-		UserID:    currentUser.ID,
-		PollID:    poll.ID,
-		OptionID:  req.OptionID,
-		TimeTaken: req.TimeTaken,
-	}
+// pollAction runs a host lifecycle action on the poll in the URL and responds
+// with the updated poll
+func pollAction(action func(poll *models.Poll, user models.User) error) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, exists := c.Get("user")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+			return
+		}
+		currentUser := user.(models.User)
 
-	if err := database.DB.Create(&vote).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record vote"})
-		return
-	}
+		poll, err := loadPoll(c.Param("id"), "")
+		if err != nil {
+			apperror.Respond(c, err)
+			return
+		}
 
-	// Broadcast vote to all clients
-	websocket.BroadcastToRoom(poll.RoomID, "vote", vote)
+		if err := action(poll, currentUser); err != nil {
+			apperror.Respond(c, err)
+			return
+		}
 
-	c.JSON(http.StatusOK, vote)
+		c.JSON(http.StatusOK, pollEvent{
+			Poll:          poll,
+			TimeRemaining: poll.GetTimeRemaining(),
+		})
+	}
 }
 
-func GetResults(c *gin.Context) {
-	pollID := c.Param("id")
+// StartPoll opens a draft or scheduled poll immediately
+var StartPoll = pollAction(startPoll)
 
-	var poll models.Poll
-	if err := database.DB.Preload("Options.Votes").First(&poll, "id = ?", pollID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Poll not found"})
-		return
-	}
+// PausePoll freezes a live poll's timer
+var PausePoll = pollAction(pausePoll)
 
-	// Calculate results
-	type OptionResult struct {
-		OptionID   uint    `json:"option_id"`
-		Text       string  `json:"text"`
-		VoteCount  int     `json:"vote_count"`
-		IsCorrect  bool    `json:"is_correct"`
-		Percentage float64 `json:"percentage"`
-	}
+// ResumePoll continues a paused poll
+var ResumePoll = pollAction(resumePoll)
+
+// EndPoll lets the host close a running poll before its timer runs out
+var EndPoll = pollAction(stopPoll)
 
-	var results []OptionResult
-	totalVotes := 0
+// RevealPoll publishes the correct answers of a closed poll
+var RevealPoll = pollAction(revealPoll)
 
-	// Count total votes
-	for _, option := range poll.Options {
-		totalVotes += len(option.Votes)
+// SchedulePoll sets a draft poll to open at a given time
+func SchedulePoll(c *gin.Context) {
+	var req SchedulePollRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	// Calculate results for each option
-	for _, option := range poll.Options {
-		voteCount := len(option.Votes)
-		percentage := 0.0
-		if totalVotes > 0 {
-			percentage = float64(voteCount) / float64(totalVotes) * 100
-		}
+	pollAction(func(poll *models.Poll, user models.User) error {
+		return schedulePoll(poll, user, req.StartAt)
+	})(c)
+}
 
-		results = append(results, OptionResult{
-			OptionID:   option.ID,
-			Text:      option.Text,
-			VoteCount: voteCount,
-			IsCorrect: option.IsCorrect,
-			Percentage: percentage,
-		})
+// ExtendPoll adds time to a live or paused poll
+func ExtendPoll(c *gin.Context) {
+	var req ExtendPollRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"poll":    poll,
-		"results": results,
-	})
+	pollAction(func(poll *models.Poll, user models.User) error {
+		return extendPoll(poll, user, req.Seconds)
+	})(c)
 }
 
-func endPoll(pollID uint) {
-	var poll models.Poll
-	if err := database.DB.First(&poll, "id = ?", pollID).Error; err != nil {
+// ShowRunoffRound broadcasts one instant-runoff round of a ranked poll
+func ShowRunoffRound(c *gin.Context) {
+	var req RunoffRoundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+	currentUser := user.(models.User)
+
+	poll, err := loadPoll(c.Param("id"), "")
+	if err != nil {
+		apperror.Respond(c, err)
 		return
 	}
 
-	poll.IsActive = false
-	if err := database.DB.Save(&poll).Error; err != nil {
+	event, err := showRunoffRound(poll, currentUser, req.Round)
+	if err != nil {
+		apperror.Respond(c, err)
 		return
 	}
 
-	// Broadcast poll end to all clients
-	websocket.BroadcastToRoom(poll.RoomID, "end_poll", poll)
-} 
\ No newline at end of file
+	c.JSON(http.StatusOK, event)
+}
@@ -0,0 +1,165 @@
+package poll
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"polling-app/internal/access"
+	"polling-app/internal/apperror"
+	"polling-app/internal/models"
+	"polling-app/internal/websocket"
+	"polling-app/pkg/database"
+)
+
+type LeaderboardEntry struct {
+	Rank           int    `json:"rank"`
+	PreviousRank   int    `json:"previous_rank"` // 0 when the participant was not ranked before
+	RankChange     int    `json:"rank_change"`   // Positive when the participant moved up
+	UserID         uint   `json:"user_id"`
+	Name           string `json:"name"`
+	Score          int    `json:"score"`
+	CorrectAnswers int    `json:"correct_answers"`
+}
+
+type Leaderboard struct {
+	RoomID     string             `json:"room_id"`
+	LastPollID uint               `json:"last_poll_id"`
+	Entries    []LeaderboardEntry `json:"entries"`
+}
+
+// scoreRow is one participant's total across a set of quiz polls
+type scoreRow struct {
+	UserID         uint
+	Name           string
+	Score          int
+	CorrectAnswers int
+}
+
+// GetLeaderboard returns the room's quiz standings across all revealed quiz
+// polls, with rank changes caused by the most recently revealed one
+func GetLeaderboard(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	var room models.Room
+	if err := database.DB.First(&room, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Room not found"})
+		return
+	}
+
+	if err := access.Require(room.ID, user.(models.User).ID, access.View); err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	leaderboard, err := buildLeaderboard(room.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load leaderboard"})
+		return
+	}
+
+	c.JSON(http.StatusOK, leaderboard)
+}
+
+// broadcastLeaderboard pushes the current standings to the room
+func broadcastLeaderboard(roomID string) {
+	leaderboard, err := buildLeaderboard(roomID)
+	if err != nil {
+		log.Printf("Failed to build leaderboard for room %s: %v", roomID, err)
+		return
+	}
+	websocket.BroadcastToRoom(roomID, websocket.EventLeaderboard, leaderboard)
+}
+
+func buildLeaderboard(roomID string) (*Leaderboard, error) {
+	leaderboard := &Leaderboard{RoomID: roomID, Entries: []LeaderboardEntry{}}
+
+	// The most recently revealed quiz poll is the one rank changes refer to.
+	// Polls revealed before RevealedAt existed fall back to their close time.
+	var last models.Poll
+	err := finishedQuizPolls(roomID).Order("COALESCE(revealed_at, closed_at) DESC").Limit(1).Find(&last).Error
+	if err != nil {
+		return nil, err
+	}
+	if last.ID == 0 {
+		return leaderboard, nil
+	}
+	leaderboard.LastPollID = last.ID
+
+	current, err := quizScores(roomID, 0)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := quizScores(roomID, last.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	previousRanks := make(map[uint]int, len(previous))
+	for i, rank := range rankScores(previous) {
+		previousRanks[previous[i].UserID] = rank
+	}
+
+	for i, rank := range rankScores(current) {
+		row := current[i]
+		entry := LeaderboardEntry{
+			Rank:           rank,
+			PreviousRank:   previousRanks[row.UserID],
+			UserID:         row.UserID,
+			Name:           row.Name,
+			Score:          row.Score,
+			CorrectAnswers: row.CorrectAnswers,
+		}
+		if entry.PreviousRank > 0 {
+			entry.RankChange = entry.PreviousRank - entry.Rank
+		}
+		leaderboard.Entries = append(leaderboard.Entries, entry)
+	}
+
+	return leaderboard, nil
+}
+
+// quizScores totals each participant's points over the room's revealed quiz
+// polls, optionally leaving one poll out, ordered from highest score down
+func quizScores(roomID string, excludePollID uint) ([]scoreRow, error) {
+	pollIDs := finishedQuizPolls(roomID).Select("id")
+	if excludePollID != 0 {
+		pollIDs = pollIDs.Where("id <> ?", excludePollID)
+	}
+
+	var rows []scoreRow
+	err := database.DB.Table("votes").
+		Select("votes.user_id, users.name, SUM(votes.points) AS score, SUM(CASE WHEN votes.correct THEN 1 ELSE 0 END) AS correct_answers").
+		Joins("JOIN users ON users.id = votes.user_id").
+		Where("votes.poll_id IN (?)", pollIDs).
+		Group("votes.user_id, users.name").
+		Order("score DESC, users.name ASC").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// finishedQuizPolls selects the room's quiz polls whose scores may be shown.
+// Closed polls are left out until they are revealed, as the leaderboard would
+// otherwise give away who answered correctly.
+func finishedQuizPolls(roomID string) *gorm.DB {
+	return database.DB.Model(&models.Poll{}).Where("room_id = ? AND mode = ? AND status = ?",
+		roomID, models.PollModeQuiz, models.PollStatusRevealed)
+}
+
+// rankScores assigns competition ranks ("1224") to rows sorted by score
+func rankScores(rows []scoreRow) []int {
+	ranks := make([]int, len(rows))
+	for i := range rows {
+		if i > 0 && rows[i].Score == rows[i-1].Score {
+			ranks[i] = ranks[i-1]
+		} else {
+			ranks[i] = i + 1
+		}
+	}
+	return ranks
+}
@@ -0,0 +1,298 @@
+package poll
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm/clause"
+	"polling-app/internal/access"
+	"polling-app/internal/apperror"
+	"polling-app/internal/models"
+	"polling-app/internal/scheduler"
+	"polling-app/internal/websocket"
+	"polling-app/pkg/database"
+)
+
+// pollEvent is the payload of every lifecycle broadcast
+type pollEvent struct {
+	*models.Poll
+	TimeRemaining float64 `json:"time_remaining"`
+}
+
+// newPollEvent builds the broadcast payload for a poll. Correct answers are
+// hidden until the poll is revealed.
+func newPollEvent(poll *models.Poll) pollEvent {
+	return pollEvent{
+		Poll:          publicPoll(poll),
+		TimeRemaining: poll.GetTimeRemaining(),
+	}
+}
+
+func broadcastPollEvent(eventType string, poll *models.Poll) {
+	websocket.BroadcastToRoom(poll.RoomID, eventType, newPollEvent(poll))
+}
+
+// savePollTransition persists the poll's lifecycle fields only if its stored
+// status is still from. A host command racing a timer, or a timer racing the
+// sweep, cannot both apply a transition.
+func savePollTransition(poll *models.Poll, from string) error {
+	result := database.DB.Model(poll).
+		Where("status = ?", from).
+		Select("status", "is_active", "scheduled_at", "start_time", "end_time", "paused_at", "paused_total", "duration", "revealed_at", "updated_at").
+		Omit(clause.Associations).
+		Updates(poll)
+	if result.Error != nil {
+		return apperror.New(http.StatusInternalServerError, "Failed to update poll")
+	}
+	if result.RowsAffected == 0 {
+		return apperror.New(http.StatusConflict, "Poll state changed, please retry")
+	}
+	return nil
+}
+
+// startPoll lets the host open a draft or scheduled poll right away
+func startPoll(poll *models.Poll, user models.User) error {
+	if err := access.Require(poll.RoomID, user.ID, access.ManagePolls); err != nil {
+		return err
+	}
+	return openPoll(poll)
+}
+
+// openPoll moves a draft or scheduled poll to live and arms its end timer
+func openPoll(poll *models.Poll) error {
+	if poll.Status != models.PollStatusDraft && poll.Status != models.PollStatusScheduled {
+		return apperror.New(http.StatusBadRequest, "Poll has already started")
+	}
+
+	from := poll.Status
+	poll.StartPoll()
+	if err := savePollTransition(poll, from); err != nil {
+		return err
+	}
+
+	scheduler.Cancel(startTimerKey(poll.ID))
+
+	// Broadcast poll start to all clients in the room, noting when it reaches
+	// each participant so answer times can be measured from that moment
+	pollID := poll.ID
+	websocket.BroadcastTracked(poll.RoomID, websocket.EventStartPoll, newPollEvent(poll), func(userID uint, at time.Time) {
+		recordDelivery(pollID, userID, at)
+	})
+
+	// Arm the timer that ends the poll; the deadline itself is persisted in
+	// EndTime so it survives restarts
+	scheduleEnd(poll)
+
+	return nil
+}
+
+// schedulePoll sets a draft poll to open automatically at startAt
+func schedulePoll(poll *models.Poll, user models.User, startAt time.Time) error {
+	if err := access.Require(poll.RoomID, user.ID, access.ManagePolls); err != nil {
+		return err
+	}
+
+	if poll.Status != models.PollStatusDraft && poll.Status != models.PollStatusScheduled {
+		return apperror.New(http.StatusBadRequest, "Only draft polls can be scheduled")
+	}
+
+	if !startAt.After(time.Now()) {
+		return apperror.New(http.StatusBadRequest, "Start time must be in the future")
+	}
+
+	from := poll.Status
+	poll.Status = models.PollStatusScheduled
+	poll.ScheduledAt = &startAt
+	if err := savePollTransition(poll, from); err != nil {
+		return err
+	}
+
+	scheduleStart(poll)
+	broadcastPollEvent(websocket.EventSchedulePoll, poll)
+
+	return nil
+}
+
+// pausePoll freezes a live poll's timer; votes are rejected while paused
+func pausePoll(poll *models.Poll, user models.User) error {
+	if err := access.Require(poll.RoomID, user.ID, access.ManagePolls); err != nil {
+		return err
+	}
+
+	if poll.Status != models.PollStatusLive {
+		return apperror.New(http.StatusBadRequest, "Poll is not live")
+	}
+
+	poll.Pause()
+	if err := savePollTransition(poll, models.PollStatusLive); err != nil {
+		return err
+	}
+
+	scheduler.Cancel(endTimerKey(poll.ID))
+	broadcastPollEvent(websocket.EventPausePoll, poll)
+
+	return nil
+}
+
+// resumePoll restarts a paused poll with the time it had left
+func resumePoll(poll *models.Poll, user models.User) error {
+	if err := access.Require(poll.RoomID, user.ID, access.ManagePolls); err != nil {
+		return err
+	}
+
+	if poll.Status != models.PollStatusPaused {
+		return apperror.New(http.StatusBadRequest, "Poll is not paused")
+	}
+
+	poll.Resume()
+	if err := savePollTransition(poll, models.PollStatusPaused); err != nil {
+		return err
+	}
+
+	scheduleEnd(poll)
+	broadcastPollEvent(websocket.EventResumePoll, poll)
+
+	return nil
+}
+
+// extendPoll adds time to a live or paused poll
+func extendPoll(poll *models.Poll, user models.User, seconds int) error {
+	if err := access.Require(poll.RoomID, user.ID, access.ManagePolls); err != nil {
+		return err
+	}
+
+	if poll.Status != models.PollStatusLive && poll.Status != models.PollStatusPaused {
+		return apperror.New(http.StatusBadRequest, "Poll is not running")
+	}
+
+	from := poll.Status
+	poll.Extend(seconds)
+	if err := savePollTransition(poll, from); err != nil {
+		return err
+	}
+
+	if poll.Status == models.PollStatusLive {
+		scheduleEnd(poll)
+	}
+	broadcastPollEvent(websocket.EventExtendPoll, poll)
+
+	return nil
+}
+
+// stopPoll ends a live or paused poll before its timer runs out
+func stopPoll(poll *models.Poll, user models.User) error {
+	if err := access.Require(poll.RoomID, user.ID, access.ManagePolls); err != nil {
+		return err
+	}
+
+	if poll.Status != models.PollStatusLive && poll.Status != models.PollStatusPaused {
+		return apperror.New(http.StatusBadRequest, "Poll is not running")
+	}
+
+	endPoll(poll.ID)
+	return nil
+}
+
+// revealPoll publishes the correct answers of a closed poll. Quiz scores count
+// towards the leaderboard from here on, since they show who answered correctly.
+func revealPoll(poll *models.Poll, user models.User) error {
+	if err := access.Require(poll.RoomID, user.ID, access.ManagePolls); err != nil {
+		return err
+	}
+
+	if poll.Status != models.PollStatusClosed {
+		return apperror.New(http.StatusBadRequest, "Poll must be closed before it is revealed")
+	}
+
+	now := time.Now()
+	poll.Status = models.PollStatusRevealed
+	poll.RevealedAt = &now
+	if err := savePollTransition(poll, models.PollStatusClosed); err != nil {
+		return err
+	}
+
+	broadcastPollEvent(websocket.EventRevealPoll, poll)
+
+	if poll.Mode == models.PollModeQuiz {
+		broadcastLeaderboard(poll.RoomID)
+	}
+
+	return nil
+}
+
+// endPoll closes a running poll immediately, regardless of its deadline
+func endPoll(pollID uint) {
+	closePoll(pollID, false)
+}
+
+// expirePoll closes a live poll whose EndTime has passed
+func expirePoll(pollID uint) {
+	closePoll(pollID, true)
+}
+
+// closePoll marks the poll closed and broadcasts the end to the room. The
+// conditional update lets timers, the sweep and host commands race on the
+// same poll: only the call that actually closes it broadcasts, so
+// end_poll is sent exactly once.
+func closePoll(pollID uint, onlyIfDue bool) {
+	query := database.DB.Model(&models.Poll{}).Where("id = ?", pollID)
+	if onlyIfDue {
+		query = query.Where("status = ? AND end_time <= ?", models.PollStatusLive, time.Now())
+	} else {
+		query = query.Where("status IN ?", []string{models.PollStatusLive, models.PollStatusPaused})
+	}
+
+	result := query.Updates(map[string]interface{}{
+		"status":    models.PollStatusClosed,
+		"is_active": false,
+		"paused_at": nil,
+		"closed_at": time.Now(),
+	})
+	if result.Error != nil {
+		log.Printf("Failed to close poll %d: %v", pollID, result.Error)
+		return
+	}
+	if result.RowsAffected == 0 {
+		return
+	}
+
+	scheduler.Cancel(endTimerKey(pollID))
+	forgetDeliveries(pollID)
+
+	var poll models.Poll
+	if err := database.DB.Preload("Options").First(&poll, "id = ?", pollID).Error; err != nil {
+		return
+	}
+
+	// Broadcast poll end to all clients
+	broadcastPollEvent(websocket.EventEndPoll, &poll)
+}
+
+// EndRoomPolls closes every running poll of a room and returns its scheduled
+// polls to drafts. It is called when the room itself closes.
+func EndRoomPolls(roomID string) {
+	var polls []models.Poll
+	err := database.DB.
+		Where("room_id = ? AND status IN ?", roomID, []string{models.PollStatusLive, models.PollStatusPaused, models.PollStatusScheduled}).
+		Find(&polls).Error
+	if err != nil {
+		log.Printf("Failed to load polls of room %s: %v", roomID, err)
+		return
+	}
+
+	for i := range polls {
+		poll := &polls[i]
+		if poll.Status != models.PollStatusScheduled {
+			endPoll(poll.ID)
+			continue
+		}
+
+		poll.Status = models.PollStatusDraft
+		poll.ScheduledAt = nil
+		if err := savePollTransition(poll, models.PollStatusScheduled); err != nil {
+			continue
+		}
+		scheduler.Cancel(startTimerKey(poll.ID))
+	}
+}
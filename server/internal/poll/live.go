@@ -0,0 +1,127 @@
+package poll
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"polling-app/internal/access"
+	"polling-app/internal/apperror"
+	"polling-app/internal/models"
+	"polling-app/internal/scheduler"
+	"polling-app/internal/websocket"
+	"polling-app/pkg/database"
+)
+
+// defaultResultsInterval is used when RESULTS_BROADCAST_INTERVAL is unset or invalid
+const defaultResultsInterval = time.Second
+
+// ResultsUpdate is the live aggregate pushed to a room while a poll collects
+// answers. It carries counts only, never individual ballots.
+type ResultsUpdate struct {
+	PollID      uint           `json:"poll_id"`
+	Results     []OptionResult `json:"results"`
+	Respondents int            `json:"respondents"`
+	Selections  int            `json:"selections"`
+	Numeric     *NumericStats  `json:"numeric,omitempty"`
+	WordCloud   []TermCount    `json:"word_cloud,omitempty"`
+}
+
+// pendingResults holds the polls whose results changed since the last flush
+var pendingResults = make(map[uint]bool)
+var pendingResultsMu sync.Mutex
+
+// queueResultsUpdate marks a poll's results as changed. Votes arriving within
+// one broadcast interval are coalesced into a single results_update.
+func queueResultsUpdate(pollID uint) {
+	pendingResultsMu.Lock()
+	pendingResults[pollID] = true
+	pendingResultsMu.Unlock()
+}
+
+// startResultsBroadcast starts the loop that flushes queued results updates
+func startResultsBroadcast() {
+	scheduler.Every(resultsInterval(), "results-broadcast", flushResultsUpdates)
+}
+
+func flushResultsUpdates() {
+	pendingResultsMu.Lock()
+	pending := pendingResults
+	pendingResults = make(map[uint]bool)
+	pendingResultsMu.Unlock()
+
+	for pollID := range pending {
+		poll, err := loadPoll(pollID, "")
+		if err != nil {
+			continue
+		}
+		broadcastResults(poll)
+	}
+}
+
+// broadcastResults pushes the public aggregate of a poll to its room
+func broadcastResults(poll *models.Poll) {
+	results, err := computeResults(publicPoll(poll))
+	if err != nil {
+		log.Printf("Failed to compute results for poll %d: %v", poll.ID, err)
+		return
+	}
+
+	websocket.BroadcastToRoom(poll.RoomID, websocket.EventResultsUpdate, ResultsUpdate{
+		PollID:      poll.ID,
+		Results:     results.Results,
+		Respondents: results.Respondents,
+		Selections:  results.Selections,
+		Numeric:     results.Numeric,
+		WordCloud:   results.WordCloud,
+	})
+}
+
+func resultsInterval() time.Duration {
+	if value := os.Getenv("RESULTS_BROADCAST_INTERVAL"); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed > 0 {
+			return parsed
+		}
+	}
+	return defaultResultsInterval
+}
+
+// GetVotes lists a poll's individual ballots with their voters. Only members
+// who manage polls may see who answered what; ballots of anonymous polls
+// carry no voter.
+func GetVotes(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+
+	poll, err := loadPoll(c.Param("id"), "")
+	if err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	if !access.Can(poll.RoomID, user.(models.User).ID, access.ManagePolls) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You are not allowed to view individual votes"})
+		return
+	}
+
+	var votes []models.Vote
+	err = database.DB.
+		Preload("User").
+		Preload("Selections").
+		Preload("Revisions").
+		Where("poll_id = ?", poll.ID).
+		Order("created_at ASC").
+		Find(&votes).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load votes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, votes)
+}
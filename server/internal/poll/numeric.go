@@ -0,0 +1,206 @@
+package poll
+
+import (
+	"math"
+	"net/http"
+	"sort"
+
+	"polling-app/internal/apperror"
+	"polling-app/internal/models"
+	"polling-app/pkg/database"
+)
+
+const (
+	// maxValueBuckets is the most histogram buckets a numeric poll reports
+	// with one bucket per allowed value; wider ranges are grouped
+	maxValueBuckets = 20
+
+	// rangeBuckets is the number of equal-width buckets for wide ranges
+	rangeBuckets = 10
+
+	// stepEpsilon absorbs floating point error when checking answers sit on
+	// the step grid
+	stepEpsilon = 1e-9
+)
+
+type HistogramBucket struct {
+	From  float64 `json:"from"`
+	To    float64 `json:"to"`
+	Count int     `json:"count"`
+}
+
+type NumericStats struct {
+	PollID     uint              `json:"poll_id"`
+	Count      int               `json:"count"`
+	Mean       float64           `json:"mean"`
+	Median     float64           `json:"median"`
+	StdDev     float64           `json:"std_dev"`
+	Min        float64           `json:"min"`
+	Max        float64           `json:"max"`
+	Q1         float64           `json:"q1"`
+	Q3         float64           `json:"q3"`
+	WithinBand int               `json:"within_band,omitempty"` // Answers within the tolerance of the correct value, once revealed
+	Histogram  []HistogramBucket `json:"histogram"`
+}
+
+// configureNumeric validates and applies the range settings of a numeric poll
+func configureNumeric(poll *models.Poll, req CreatePollRequest) error {
+	if len(req.Options) > 0 || req.CorrectID != 0 || len(req.CorrectIDs) > 0 {
+		return apperror.New(http.StatusBadRequest, "Numeric polls take no options")
+	}
+	if req.MinValue == nil || req.MaxValue == nil || *req.MinValue >= *req.MaxValue {
+		return apperror.New(http.StatusBadRequest, "Numeric polls need min_value below max_value")
+	}
+
+	poll.MinValue = *req.MinValue
+	poll.MaxValue = *req.MaxValue
+	poll.Step = req.Step
+	if poll.Step == 0 {
+		poll.Step = 1
+	}
+	if poll.Step > poll.MaxValue-poll.MinValue {
+		return apperror.New(http.StatusBadRequest, "step is larger than the range")
+	}
+
+	if req.CorrectValue != nil {
+		if *req.CorrectValue < poll.MinValue || *req.CorrectValue > poll.MaxValue {
+			return apperror.New(http.StatusBadRequest, "correct_value must be within the range")
+		}
+		correct := *req.CorrectValue
+		poll.CorrectValue = &correct
+		poll.Tolerance = req.Tolerance
+	}
+	if poll.Mode == models.PollModeQuiz && poll.CorrectValue == nil {
+		return apperror.New(http.StatusBadRequest, "Quiz polls need a correct_value")
+	}
+
+	poll.MinChoices = 0
+	poll.MaxChoices = 0
+	return nil
+}
+
+// checkNumericAnswer validates a numeric answer against the poll's range and
+// step and returns the credit it earns
+func checkNumericAnswer(poll *models.Poll, value *float64) (float64, error) {
+	if value == nil {
+		return 0, apperror.New(http.StatusBadRequest, "value is required")
+	}
+	if *value < poll.MinValue || *value > poll.MaxValue {
+		return 0, apperror.New(http.StatusBadRequest, "value is out of range")
+	}
+
+	steps := (*value - poll.MinValue) / poll.Step
+	if math.Abs(steps-math.Round(steps)) > stepEpsilon*math.Max(1, steps) {
+		return 0, apperror.New(http.StatusBadRequest, "value is not a multiple of the step")
+	}
+
+	if withinTolerance(poll, *value) {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func withinTolerance(poll *models.Poll, value float64) bool {
+	return poll.CorrectValue != nil && math.Abs(value-*poll.CorrectValue) <= poll.Tolerance+stepEpsilon
+}
+
+// computeNumericStats loads the answers of a numeric poll and summarises them
+func computeNumericStats(poll *models.Poll) (*NumericStats, error) {
+	var values []float64
+	err := database.DB.Model(&models.Vote{}).
+		Where("poll_id = ? AND value IS NOT NULL", poll.ID).
+		Pluck("value", &values).Error
+	if err != nil {
+		return nil, err
+	}
+	return summarizeValues(poll, values), nil
+}
+
+// summarizeValues computes the distribution statistics of numeric answers.
+// Quartiles use linear interpolation between closest ranks.
+func summarizeValues(poll *models.Poll, values []float64) *NumericStats {
+	stats := &NumericStats{
+		PollID:    poll.ID,
+		Count:     len(values),
+		Histogram: histogram(poll, values),
+	}
+	if len(values) == 0 {
+		return stats
+	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	sum := 0.0
+	for _, value := range sorted {
+		sum += value
+	}
+	stats.Mean = sum / float64(len(sorted))
+
+	variance := 0.0
+	for _, value := range sorted {
+		variance += (value - stats.Mean) * (value - stats.Mean)
+	}
+	stats.StdDev = math.Sqrt(variance / float64(len(sorted)))
+
+	stats.Min = sorted[0]
+	stats.Max = sorted[len(sorted)-1]
+	stats.Q1 = quantile(sorted, 0.25)
+	stats.Median = quantile(sorted, 0.5)
+	stats.Q3 = quantile(sorted, 0.75)
+
+	if poll.CorrectValue != nil {
+		for _, value := range sorted {
+			if withinTolerance(poll, value) {
+				stats.WithinBand++
+			}
+		}
+	}
+
+	return stats
+}
+
+func quantile(sorted []float64, q float64) float64 {
+	position := q * float64(len(sorted)-1)
+	lower := int(math.Floor(position))
+	upper := int(math.Ceil(position))
+	if lower == upper {
+		return sorted[lower]
+	}
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(position-float64(lower))
+}
+
+// histogram buckets answers over the poll's range. Small ranges get one
+// bucket per allowed value; larger ones are split into equal-width buckets.
+func histogram(poll *models.Poll, values []float64) []HistogramBucket {
+	span := poll.MaxValue - poll.MinValue
+	if span <= 0 || poll.Step <= 0 {
+		return []HistogramBucket{}
+	}
+
+	count := int(math.Floor(span/poll.Step+stepEpsilon)) + 1
+	width := poll.Step
+	if count > maxValueBuckets {
+		count = rangeBuckets
+		width = span / rangeBuckets
+	}
+
+	buckets := make([]HistogramBucket, count)
+	for i := range buckets {
+		buckets[i].From = poll.MinValue + float64(i)*width
+		buckets[i].To = math.Min(buckets[i].From+width, poll.MaxValue)
+	}
+
+	for _, value := range values {
+		index := int(math.Floor((value-poll.MinValue)/width + stepEpsilon))
+		if index >= count {
+			index = count - 1
+		}
+		if index < 0 {
+			index = 0
+		}
+		buckets[index].Count++
+	}
+
+	return buckets
+}
@@ -0,0 +1,230 @@
+package poll
+
+import (
+	"net/http"
+	"sort"
+
+	"polling-app/internal/access"
+	"polling-app/internal/apperror"
+	"polling-app/internal/models"
+	"polling-app/internal/websocket"
+	"polling-app/pkg/database"
+)
+
+type RunoffTally struct {
+	OptionID uint `json:"option_id"`
+	Votes    int  `json:"votes"`
+}
+
+type RunoffTransfer struct {
+	OptionID uint `json:"option_id"`
+	Votes    int  `json:"votes"`
+}
+
+// RunoffRound is one round of an instant-runoff count. Eliminated is the
+// option dropped at the end of the round and Transfers shows where its
+// ballots went next; ballots with no remaining preference become exhausted.
+type RunoffRound struct {
+	Round      int              `json:"round"`
+	Tallies    []RunoffTally    `json:"tallies"`
+	Exhausted  int              `json:"exhausted"`
+	Eliminated uint             `json:"eliminated,omitempty"`
+	Transfers  []RunoffTransfer `json:"transfers,omitempty"`
+	Winner     uint             `json:"winner,omitempty"`
+}
+
+type BordaScore struct {
+	OptionID uint `json:"option_id"`
+	Points   int  `json:"points"`
+}
+
+type RankedResults struct {
+	Rounds           []RunoffRound `json:"rounds"`
+	EliminationOrder []uint        `json:"elimination_order"`
+	Winner           uint          `json:"winner,omitempty"`
+	Borda            []BordaScore  `json:"borda"`
+}
+
+type RunoffRoundEvent struct {
+	PollID uint        `json:"poll_id"`
+	Total  int         `json:"total_rounds"`
+	Round  RunoffRound `json:"round"`
+}
+
+// computeRankedResults loads the ranked ballots of a poll and runs both the
+// instant-runoff and the Borda count over them
+func computeRankedResults(poll *models.Poll) (*RankedResults, error) {
+	var selections []models.Selection
+	err := database.DB.Where("poll_id = ?", poll.ID).
+		Order("vote_id ASC, rank ASC").
+		Find(&selections).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var ballots [][]uint
+	var lastVoteID uint
+	for _, selection := range selections {
+		if len(ballots) == 0 || selection.VoteID != lastVoteID {
+			ballots = append(ballots, nil)
+			lastVoteID = selection.VoteID
+		}
+		ballots[len(ballots)-1] = append(ballots[len(ballots)-1], selection.OptionID)
+	}
+
+	optionIDs := make([]uint, len(poll.Options))
+	for i, option := range poll.Options {
+		optionIDs[i] = option.ID
+	}
+
+	borda := bordaCount(optionIDs, ballots)
+	results := instantRunoff(optionIDs, ballots, borda)
+	results.Borda = borda
+	return results, nil
+}
+
+// bordaCount awards each option n-1 points for a first preference, n-2 for a
+// second and so on, where n is the number of options. Unranked options get
+// nothing. Scores are returned highest first.
+func bordaCount(optionIDs []uint, ballots [][]uint) []BordaScore {
+	points := make(map[uint]int, len(optionIDs))
+	for _, ballot := range ballots {
+		for position, optionID := range ballot {
+			points[optionID] += len(optionIDs) - 1 - position
+		}
+	}
+
+	scores := make([]BordaScore, len(optionIDs))
+	for i, optionID := range optionIDs {
+		scores[i] = BordaScore{OptionID: optionID, Points: points[optionID]}
+	}
+	sort.SliceStable(scores, func(i, j int) bool {
+		return scores[i].Points > scores[j].Points
+	})
+	return scores
+}
+
+// instantRunoff counts each ballot for its highest-ranked remaining option
+// until one option holds a majority of the continuing ballots. The option
+// with the fewest votes is eliminated each round; ties are broken by the
+// lower Borda score, then by the later position in the option list.
+func instantRunoff(optionIDs []uint, ballots [][]uint, borda []BordaScore) *RankedResults {
+	results := &RankedResults{Rounds: []RunoffRound{}, EliminationOrder: []uint{}}
+	if len(ballots) == 0 || len(optionIDs) == 0 {
+		return results
+	}
+
+	bordaPoints := make(map[uint]int, len(borda))
+	for _, score := range borda {
+		bordaPoints[score.OptionID] = score.Points
+	}
+
+	remaining := make(map[uint]bool, len(optionIDs))
+	for _, optionID := range optionIDs {
+		remaining[optionID] = true
+	}
+
+	// topChoice returns the ballot's highest-ranked option still in the count
+	topChoice := func(ballot []uint) uint {
+		for _, optionID := range ballot {
+			if remaining[optionID] {
+				return optionID
+			}
+		}
+		return 0
+	}
+
+	for round := 1; ; round++ {
+		counts := make(map[uint]int, len(remaining))
+		exhausted := 0
+		for _, ballot := range ballots {
+			if choice := topChoice(ballot); choice != 0 {
+				counts[choice]++
+			} else {
+				exhausted++
+			}
+		}
+
+		current := RunoffRound{Round: round, Exhausted: exhausted, Tallies: []RunoffTally{}}
+		for _, optionID := range optionIDs {
+			if remaining[optionID] {
+				current.Tallies = append(current.Tallies, RunoffTally{OptionID: optionID, Votes: counts[optionID]})
+			}
+		}
+
+		continuing := len(ballots) - exhausted
+		leader, loser := current.Tallies[0], current.Tallies[0]
+		for _, tally := range current.Tallies[1:] {
+			if tally.Votes > leader.Votes {
+				leader = tally
+			}
+			if tally.Votes < loser.Votes ||
+				(tally.Votes == loser.Votes && bordaPoints[tally.OptionID] <= bordaPoints[loser.OptionID]) {
+				loser = tally
+			}
+		}
+
+		if len(current.Tallies) == 1 || (continuing > 0 && leader.Votes*2 > continuing) {
+			current.Winner = leader.OptionID
+			results.Winner = leader.OptionID
+			results.Rounds = append(results.Rounds, current)
+			return results
+		}
+
+		// Eliminate the weakest option and follow its ballots to their next
+		// remaining preference
+		current.Eliminated = loser.OptionID
+		results.EliminationOrder = append(results.EliminationOrder, loser.OptionID)
+
+		var moved [][]uint
+		for _, ballot := range ballots {
+			if topChoice(ballot) == loser.OptionID {
+				moved = append(moved, ballot)
+			}
+		}
+		remaining[loser.OptionID] = false
+
+		transfers := make(map[uint]int)
+		for _, ballot := range moved {
+			transfers[topChoice(ballot)]++
+		}
+
+		for _, optionID := range optionIDs {
+			if votes := transfers[optionID]; votes > 0 {
+				current.Transfers = append(current.Transfers, RunoffTransfer{OptionID: optionID, Votes: votes})
+			}
+		}
+
+		results.Rounds = append(results.Rounds, current)
+	}
+}
+
+// showRunoffRound broadcasts one round of a ranked poll's instant-runoff so
+// the host can step through the count with the room
+func showRunoffRound(poll *models.Poll, user models.User, round int) (*RunoffRoundEvent, error) {
+	if err := access.Require(poll.RoomID, user.ID, access.ManagePolls); err != nil {
+		return nil, err
+	}
+
+	if poll.Type != models.PollTypeRanked {
+		return nil, apperror.New(http.StatusBadRequest, "Poll is not a ranked poll")
+	}
+
+	ranked, err := computeRankedResults(poll)
+	if err != nil {
+		return nil, apperror.New(http.StatusInternalServerError, "Failed to calculate results")
+	}
+
+	if round < 1 || round > len(ranked.Rounds) {
+		return nil, apperror.New(http.StatusBadRequest, "Round is out of range")
+	}
+
+	event := &RunoffRoundEvent{
+		PollID: poll.ID,
+		Total:  len(ranked.Rounds),
+		Round:  ranked.Rounds[round-1],
+	}
+	websocket.BroadcastToRoom(poll.RoomID, websocket.EventRunoffRound, event)
+
+	return event, nil
+}
@@ -0,0 +1,113 @@
+package poll
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInstantRunoff(t *testing.T) {
+	tests := []struct {
+		name       string
+		options    []uint
+		ballots    [][]uint
+		winner     uint
+		eliminated []uint
+		exhausted  []int // Exhausted ballots in each round
+	}{
+		{
+			name:       "no ballots",
+			options:    []uint{1, 2},
+			eliminated: []uint{},
+		},
+		{
+			name:       "first round majority",
+			options:    []uint{1, 2, 3},
+			ballots:    [][]uint{{1, 2}, {1, 3}, {2, 1}},
+			winner:     1,
+			eliminated: []uint{},
+			exhausted:  []int{0},
+		},
+		{
+			name:       "eliminated ballots transfer",
+			options:    []uint{1, 2, 3},
+			ballots:    [][]uint{{1}, {1}, {2}, {2}, {3, 2}},
+			winner:     2,
+			eliminated: []uint{3},
+			exhausted:  []int{0, 0},
+		},
+		{
+			name:       "ballots without a next preference are exhausted",
+			options:    []uint{1, 2, 3},
+			ballots:    [][]uint{{1, 2}, {1}, {2}, {3}, {3}},
+			winner:     1,
+			eliminated: []uint{2, 3},
+			exhausted:  []int{0, 1, 3},
+		},
+		{
+			name:       "tie for last goes to the lower Borda score",
+			options:    []uint{1, 3, 2},
+			ballots:    [][]uint{{1, 2}, {1, 2}, {2, 3}, {3, 1}},
+			winner:     1,
+			eliminated: []uint{3},
+			exhausted:  []int{0, 0},
+		},
+		{
+			name:       "tie on Borda score drops the later option",
+			options:    []uint{1, 2},
+			ballots:    [][]uint{{1}, {2}},
+			winner:     1,
+			eliminated: []uint{2},
+			exhausted:  []int{0, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := instantRunoff(tt.options, tt.ballots, bordaCount(tt.options, tt.ballots))
+
+			if results.Winner != tt.winner {
+				t.Errorf("winner = %d, want %d", results.Winner, tt.winner)
+			}
+			if !reflect.DeepEqual(results.EliminationOrder, tt.eliminated) {
+				t.Errorf("elimination order = %v, want %v", results.EliminationOrder, tt.eliminated)
+			}
+
+			var exhausted []int
+			for _, round := range results.Rounds {
+				exhausted = append(exhausted, round.Exhausted)
+			}
+			if !reflect.DeepEqual(exhausted, tt.exhausted) {
+				t.Errorf("exhausted per round = %v, want %v", exhausted, tt.exhausted)
+			}
+		})
+	}
+}
+
+func TestInstantRunoffTransfers(t *testing.T) {
+	options := []uint{1, 2, 3}
+	ballots := [][]uint{{1}, {1}, {2}, {2}, {3, 2}, {3, 1}}
+
+	results := instantRunoff(options, ballots, bordaCount(options, ballots))
+	if len(results.Rounds) != 3 {
+		t.Fatalf("got %d rounds, want 3", len(results.Rounds))
+	}
+
+	// All three tie on votes; 3 has the lowest Borda score and its ballots
+	// split between the other two
+	first := results.Rounds[0]
+	want := []RunoffTransfer{{OptionID: 1, Votes: 1}, {OptionID: 2, Votes: 1}}
+	if first.Eliminated != 3 || !reflect.DeepEqual(first.Transfers, want) {
+		t.Errorf("round 1 eliminated %d with transfers %v, want 3 with %v", first.Eliminated, first.Transfers, want)
+	}
+
+	// Every ballot counting for 2 has no preference left, so nothing moves
+	second := results.Rounds[1]
+	if second.Eliminated != 2 || len(second.Transfers) != 0 {
+		t.Errorf("round 2 eliminated %d with transfers %v, want 2 with none", second.Eliminated, second.Transfers)
+	}
+
+	last := results.Rounds[2]
+	if last.Winner != 1 || last.Exhausted != 3 {
+		t.Errorf("round 3 winner %d with %d exhausted, want 1 with 3", last.Winner, last.Exhausted)
+	}
+}
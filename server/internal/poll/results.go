@@ -0,0 +1,191 @@
+package poll
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"polling-app/internal/access"
+	"polling-app/internal/apperror"
+	"polling-app/internal/models"
+	"polling-app/pkg/database"
+)
+
+type OptionResult struct {
+	OptionID             uint    `json:"option_id"`
+	Text                 string  `json:"text"`
+	VoteCount            int     `json:"vote_count"`
+	IsCorrect            bool    `json:"is_correct"`
+	Percentage           float64 `json:"percentage"`            // Share of all selections
+	RespondentPercentage float64 `json:"respondent_percentage"` // Share of respondents who picked the option
+}
+
+type PollResults struct {
+	Poll        *models.Poll   `json:"poll,omitempty"`
+	Results     []OptionResult `json:"results"`
+	Respondents int            `json:"respondents"`
+	Selections  int            `json:"selections"`
+	Ranked      *RankedResults `json:"ranked,omitempty"`
+	Numeric     *NumericStats  `json:"numeric,omitempty"`
+	WordCloud   []TermCount    `json:"word_cloud,omitempty"`
+	Responses   []TextResponse `json:"responses,omitempty"`
+}
+
+func GetResults(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+	currentUser := user.(models.User)
+
+	pollID := c.Param("id")
+
+	var poll models.Poll
+	if err := database.DB.Preload("Options").First(&poll, "id = ?", pollID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Poll not found"})
+		return
+	}
+
+	if err := access.Require(poll.RoomID, currentUser.ID, access.View); err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	// Correct answers stay hidden from participants until the poll is revealed
+	showAnswers := poll.Status == models.PollStatusRevealed
+	if !showAnswers {
+		showAnswers = access.Can(poll.RoomID, currentUser.ID, access.ManagePolls)
+	}
+	if !showAnswers {
+		hideAnswers(&poll)
+	}
+
+	results, err := computeResults(&poll)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate results"})
+		return
+	}
+	results.Poll = &poll
+
+	c.JSON(http.StatusOK, results)
+}
+
+// computeResults tallies a poll's ballots. The poll's Options must be loaded;
+// their IsCorrect flags are copied as they are, so callers hide them first
+// when needed.
+func computeResults(poll *models.Poll) (*PollResults, error) {
+	results := &PollResults{Results: []OptionResult{}}
+
+	var respondents int64
+	if err := database.DB.Model(&models.Vote{}).Where("poll_id = ?", poll.ID).Count(&respondents).Error; err != nil {
+		return nil, err
+	}
+	results.Respondents = int(respondents)
+
+	var counts []struct {
+		OptionID uint
+		Count    int
+	}
+	query := database.DB.Model(&models.Selection{}).
+		Select("option_id, COUNT(*) AS count").
+		Where("poll_id = ?", poll.ID)
+	if poll.Type == models.PollTypeRanked {
+		// A ranked ballot selects every option it ranks; only first
+		// preferences count as votes
+		query = query.Where("rank = ?", 1)
+	}
+	err := query.Group("option_id").Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byOption := make(map[uint]int, len(counts))
+	for _, row := range counts {
+		byOption[row.OptionID] = row.Count
+		results.Selections += row.Count
+	}
+
+	// Calculate results for each option
+	for _, option := range poll.Options {
+		voteCount := byOption[option.ID]
+		result := OptionResult{
+			OptionID:  option.ID,
+			Text:      option.Text,
+			VoteCount: voteCount,
+			IsCorrect: option.IsCorrect,
+		}
+		if results.Selections > 0 {
+			result.Percentage = float64(voteCount) / float64(results.Selections) * 100
+		}
+		if results.Respondents > 0 {
+			result.RespondentPercentage = float64(voteCount) / float64(results.Respondents) * 100
+		}
+		results.Results = append(results.Results, result)
+	}
+
+	if poll.Type == models.PollTypeText {
+		responses, err := approvedResponses(poll.ID)
+		if err != nil {
+			return nil, err
+		}
+		results.Responses = responses
+		results.WordCloud = wordCloudFor(responses)
+	}
+
+	if poll.Type == models.PollTypeNumeric {
+		numeric, err := computeNumericStats(poll)
+		if err != nil {
+			return nil, err
+		}
+		results.Numeric = numeric
+	}
+
+	if poll.Type == models.PollTypeRanked {
+		ranked, err := computeRankedResults(poll)
+		if err != nil {
+			return nil, err
+		}
+		results.Ranked = ranked
+	}
+
+	return results, nil
+}
+
+// hideAnswers clears the correct answers of a poll: its options' flags and
+// the correct value of a numeric poll
+func hideAnswers(poll *models.Poll) {
+	for i := range poll.Options {
+		poll.Options[i].IsCorrect = false
+	}
+	poll.CorrectValue = nil
+	poll.Tolerance = 0
+}
+
+// publicPoll returns a copy of the poll that is safe to broadcast to the
+// room: correct answers are hidden until the poll has been revealed
+func publicPoll(poll *models.Poll) *models.Poll {
+	public := *poll
+	if poll.Status != models.PollStatusRevealed {
+		public.Options = append([]models.Option(nil), poll.Options...)
+		hideAnswers(&public)
+	}
+	return &public
+}
+
+// ballot is a vote as returned to its voter before the poll is revealed. The
+// nil fields shadow the vote's own, so whether it was correct and what it
+// scored are left out of the JSON.
+type ballot struct {
+	*models.Vote
+	Correct *bool `json:"correct,omitempty"`
+	Points  *int  `json:"points,omitempty"`
+}
+
+// voterBallot returns the vote as its voter may see it: like the poll's
+// correct answers, its score stays hidden until the poll has been revealed
+func voterBallot(poll *models.Poll, vote *models.Vote) interface{} {
+	if poll.Status == models.PollStatusRevealed {
+		return vote
+	}
+	return ballot{Vote: vote}
+}
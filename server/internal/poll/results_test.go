@@ -0,0 +1,59 @@
+package poll
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"polling-app/internal/models"
+	"polling-app/internal/testdb"
+	"polling-app/pkg/database"
+)
+
+func TestGetResultsOfDeletedRoom(t *testing.T) {
+	testdb.Open(t)
+	poll, voter := livePoll(t)
+
+	if _, err := castVote(poll, voter, VoteRequest{OptionID: poll.Options[0].ID}); err != nil {
+		t.Fatalf("vote: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/polls/:id/results", func(c *gin.Context) {
+		c.Set("user", voter)
+	}, GetResults)
+
+	getResults := func() int {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/polls/%d/results", poll.ID), nil))
+		return recorder.Code
+	}
+
+	if code := getResults(); code != http.StatusOK {
+		t.Fatalf("results of a live room: status %d, want %d", code, http.StatusOK)
+	}
+
+	late := models.User{Email: fmt.Sprintf("late%d@example.com", poll.ID), Password: "x", Name: "Late"}
+	if err := database.DB.Create(&late).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	member := models.RoomParticipant{RoomID: poll.RoomID, UserID: late.ID, Role: models.RoleParticipant}
+	if err := database.DB.Create(&member).Error; err != nil {
+		t.Fatalf("add member: %v", err)
+	}
+
+	// With a retention period the room is only soft-deleted and its members stay
+	if err := database.DB.Delete(&models.Room{}, "id = ?", poll.RoomID).Error; err != nil {
+		t.Fatalf("delete room: %v", err)
+	}
+
+	if code := getResults(); code != http.StatusForbidden {
+		t.Errorf("results of a deleted room: status %d, want %d", code, http.StatusForbidden)
+	}
+	if _, err := castVote(poll, late, VoteRequest{OptionID: poll.Options[1].ID}); err == nil {
+		t.Error("vote accepted in a deleted room")
+	}
+}
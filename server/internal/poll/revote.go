@@ -0,0 +1,70 @@
+package poll
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"polling-app/internal/models"
+)
+
+// ballotColumns are the vote columns a revote replaces
+var ballotColumns = []string{
+	"option_id", "text", "moderation", "value",
+	"time_taken", "server_time_taken", "client_time_taken",
+	"correct", "points", "revised_at",
+}
+
+// reviseBallot replaces the answer on a voter's existing ballot, which the
+// caller has locked with its selections loaded. The previous answer is kept
+// as a revision, and the ballot and its selections change in the caller's
+// transaction so results never count both. On return ballot holds the
+// updated vote.
+func reviseBallot(tx *gorm.DB, previous *models.Vote, ballot *models.Vote, now time.Time) error {
+	if err := tx.Create(newRevision(previous)).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("vote_id = ?", previous.ID).Delete(&models.Selection{}).Error; err != nil {
+		return err
+	}
+
+	ballot.RevisedAt = &now
+	if err := tx.Model(previous).Select(ballotColumns).Omit(clause.Associations).Updates(ballot).Error; err != nil {
+		return err
+	}
+
+	ballot.ID = previous.ID
+	ballot.CreatedAt = previous.CreatedAt
+	for i := range ballot.Selections {
+		ballot.Selections[i].VoteID = previous.ID
+	}
+	if len(ballot.Selections) > 0 {
+		return tx.Create(&ballot.Selections).Error
+	}
+	return nil
+}
+
+// newRevision snapshots the answer currently on a ballot
+func newRevision(vote *models.Vote) *models.VoteRevision {
+	optionIDs := make([]string, len(vote.Selections))
+	for i, selection := range vote.Selections {
+		optionIDs[i] = strconv.FormatUint(uint64(selection.OptionID), 10)
+	}
+
+	castAt := vote.CreatedAt
+	if vote.RevisedAt != nil {
+		castAt = *vote.RevisedAt
+	}
+
+	return &models.VoteRevision{
+		VoteID:    vote.ID,
+		PollID:    vote.PollID,
+		OptionIDs: strings.Join(optionIDs, ","),
+		Text:      vote.Text,
+		Value:     vote.Value,
+		CastAt:    castAt,
+	}
+}
@@ -0,0 +1,31 @@
+package poll
+
+import (
+	"math"
+
+	"polling-app/internal/models"
+)
+
+// Quiz scoring defaults applied when a quiz poll is created without them
+const (
+	defaultMaxPoints = 1000
+	defaultDecay     = 0.5
+)
+
+// scoreAnswer returns the points for an answer to a quiz poll. A fully
+// correct answer given instantly earns MaxPoints; the award falls linearly
+// with the time taken, so answering at the deadline earns
+// MaxPoints * (1 - Decay). Partially correct answers earn the same share of
+// that award as their credit. Answers to regular polls earn nothing.
+func scoreAnswer(poll *models.Poll, credit float64, timeTaken float64) int {
+	if poll.Mode != models.PollModeQuiz || credit <= 0 {
+		return 0
+	}
+
+	elapsed := 1.0
+	if poll.Duration > 0 {
+		elapsed = math.Min(math.Max(timeTaken/float64(poll.Duration), 0), 1)
+	}
+
+	return int(math.Round(float64(poll.MaxPoints) * (1 - poll.Decay*elapsed) * math.Min(credit, 1)))
+}
@@ -0,0 +1,169 @@
+package poll
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"polling-app/internal/access"
+	"polling-app/internal/apperror"
+	"polling-app/internal/models"
+	"polling-app/pkg/database"
+)
+
+// loadPoll fetches a poll and its options by ID. When roomID is set the poll must belong to
+// that room, which keeps WebSocket commands scoped to the client's room.
+func loadPoll(pollID interface{}, roomID string) (*models.Poll, error) {
+	var poll models.Poll
+	if err := database.DB.Preload("Options").First(&poll, "id = ?", pollID).Error; err != nil {
+		return nil, apperror.New(http.StatusNotFound, "Poll not found")
+	}
+
+	if roomID != "" && poll.RoomID != roomID {
+		return nil, apperror.New(http.StatusNotFound, "Poll not found")
+	}
+
+	return &poll, nil
+}
+
+// checkOpen reports why a poll cannot take votes at now, judged by the
+// server clock rather than by whether the closing timer has run yet
+func checkOpen(poll *models.Poll, now time.Time) error {
+	if poll.Status == models.PollStatusPaused {
+		return apperror.New(http.StatusBadRequest, "Poll is paused")
+	}
+	if !poll.IsActive || poll.Status != models.PollStatusLive {
+		return apperror.New(http.StatusBadRequest, "Poll is not active")
+	}
+	if !now.Before(poll.EndTime) {
+		return apperror.New(http.StatusBadRequest, "Poll has ended")
+	}
+	return nil
+}
+
+// castVote records the user's vote on an active poll and queues a results
+// update. The ballot is validated up front and then written in a transaction
+// that holds a share lock on the poll row, so the poll cannot close, pause or
+// take a second ballot from the same voter while the vote is recorded.
+func castVote(poll *models.Poll, user models.User, req VoteRequest) (*models.Vote, error) {
+	now := time.Now()
+	if err := checkOpen(poll, now); err != nil {
+		return nil, err
+	}
+
+	if err := access.Require(poll.RoomID, user.ID, access.Participate); err != nil {
+		return nil, err
+	}
+
+	vote := models.Vote{
+		PollID: poll.ID,
+	}
+	if !poll.Anonymous {
+		vote.UserID = &user.ID
+	}
+
+	credit, err := fillBallot(poll, req, &vote)
+	if err != nil {
+		return nil, err
+	}
+
+	// Answer time is measured by the server; the client's value is only a hint
+	vote.TimeTaken, vote.ServerTimeTaken = measureAnswerTime(poll, user.ID, req.TimeTaken, now)
+	vote.ClientTimeTaken = req.TimeTaken
+	vote.Correct = credit >= 1
+	vote.Points = scoreAnswer(poll, credit, vote.TimeTaken)
+
+	var previous *models.Vote
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var current models.Poll
+		if err := tx.Clauses(clause.Locking{Strength: "SHARE"}).First(&current, poll.ID).Error; err != nil {
+			return apperror.New(http.StatusNotFound, "Poll not found")
+		}
+		if err := checkOpen(&current, now); err != nil {
+			return err
+		}
+
+		// Anonymous ballots carry no user, so their voters are tracked by
+		// receipt instead
+		if poll.Anonymous {
+			receipt := models.VoteReceipt{PollID: poll.ID, UserID: user.ID}
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&receipt)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return apperror.New(http.StatusBadRequest, "Already voted")
+			}
+			return insertVote(tx, &vote)
+		}
+
+		var existing models.Vote
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("Selections", func(db *gorm.DB) *gorm.DB {
+				return db.Order("rank ASC, id ASC")
+			}).
+			Where("poll_id = ? AND user_id = ?", poll.ID, user.ID).
+			First(&existing).Error
+		if err == nil {
+			if !poll.AllowRevote {
+				return apperror.New(http.StatusBadRequest, "Already voted")
+			}
+			previous = &existing
+			return reviseBallot(tx, &existing, &vote, now)
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		return insertVote(tx, &vote)
+	})
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, apperror.New(http.StatusInternalServerError, "Failed to record vote")
+	}
+
+	if poll.Type == models.PollTypeText {
+		// A changed answer leaves the room until it is approved again
+		if previous != nil && previous.Moderation == models.ModerationApproved && vote.Moderation != models.ModerationApproved {
+			withdrawResponse(poll, &vote)
+		}
+		// Free-text answers only reach the room once they are approved;
+		// until then they wait in the moderators' queue
+		switch vote.Moderation {
+		case models.ModerationApproved:
+			publishResponse(poll, &vote)
+		case models.ModerationPending:
+			queueResponse(poll, &vote)
+		}
+	}
+
+	// The room only sees aggregated counts, coalesced across votes
+	queueResultsUpdate(poll.ID)
+
+	return &vote, nil
+}
+
+// insertVote stores a new ballot and its selections. The unique index on
+// (poll, voter) turns a concurrent second ballot into "Already voted".
+func insertVote(tx *gorm.DB, vote *models.Vote) error {
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Omit(clause.Associations).Create(vote)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return apperror.New(http.StatusBadRequest, "Already voted")
+	}
+
+	for i := range vote.Selections {
+		vote.Selections[i].VoteID = vote.ID
+	}
+	if len(vote.Selections) > 0 {
+		return tx.Create(&vote.Selections).Error
+	}
+	return nil
+}
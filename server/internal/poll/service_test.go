@@ -0,0 +1,140 @@
+package poll
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/gorm/clause"
+	"polling-app/internal/apperror"
+	"polling-app/internal/models"
+	"polling-app/internal/testdb"
+	"polling-app/pkg/database"
+)
+
+// livePoll creates a room with one participant and a live single choice poll
+func livePoll(t *testing.T) (*models.Poll, models.User) {
+	t.Helper()
+
+	suffix := time.Now().UnixNano()
+	host := models.User{Email: fmt.Sprintf("host%d@example.com", suffix), Password: "x", Name: "Host"}
+	voter := models.User{Email: fmt.Sprintf("voter%d@example.com", suffix), Password: "x", Name: "Voter"}
+	for _, user := range []*models.User{&host, &voter} {
+		if err := database.DB.Create(user).Error; err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+
+	room := models.Room{Name: "Test", HostID: host.ID}
+	if err := database.DB.Create(&room).Error; err != nil {
+		t.Fatalf("create room: %v", err)
+	}
+	members := []models.RoomParticipant{
+		{RoomID: room.ID, UserID: host.ID, Role: models.RoleOwner},
+		{RoomID: room.ID, UserID: voter.ID, Role: models.RoleParticipant},
+	}
+	if err := database.DB.Create(&members).Error; err != nil {
+		t.Fatalf("add members: %v", err)
+	}
+
+	poll := models.Poll{
+		RoomID:   room.ID,
+		Question: "Pick one",
+		Duration: 60,
+		Type:     models.PollTypeSingle,
+		Mode:     models.PollModePoll,
+		Options:  []models.Option{{Text: "A"}, {Text: "B"}},
+	}
+	poll.StartPoll()
+	if err := database.DB.Create(&poll).Error; err != nil {
+		t.Fatalf("create poll: %v", err)
+	}
+	return &poll, voter
+}
+
+func TestCastVoteConcurrentBallotsFromOneVoter(t *testing.T) {
+	testdb.Open(t)
+	poll, voter := livePoll(t)
+
+	const attempts = 10
+	var wg sync.WaitGroup
+	errs := make([]error, attempts)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = castVote(poll, voter, VoteRequest{OptionID: poll.Options[i%2].ID})
+		}(i)
+	}
+	wg.Wait()
+
+	accepted := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			accepted++
+		case apperror.StatusOf(err) != http.StatusBadRequest || err.Error() != "Already voted":
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	if accepted != 1 {
+		t.Errorf("accepted %d ballots, want 1", accepted)
+	}
+
+	var stored int64
+	database.DB.Model(&models.Vote{}).Where("poll_id = ? AND user_id = ?", poll.ID, voter.ID).Count(&stored)
+	if stored != 1 {
+		t.Errorf("stored %d ballots, want 1", stored)
+	}
+}
+
+func TestClosePollWaitsForVoteInProgress(t *testing.T) {
+	testdb.Open(t)
+	poll, voter := livePoll(t)
+
+	// Hold the share lock castVote takes while it records a ballot
+	tx := database.DB.Begin()
+	var locked models.Poll
+	if err := tx.Clauses(clause.Locking{Strength: "SHARE"}).First(&locked, poll.ID).Error; err != nil {
+		tx.Rollback()
+		t.Fatalf("lock poll: %v", err)
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		endPoll(poll.ID)
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+		tx.Rollback()
+		t.Fatal("poll closed while a vote held its share lock")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("poll did not close after the vote committed")
+	}
+
+	var stored models.Poll
+	if err := database.DB.First(&stored, poll.ID).Error; err != nil {
+		t.Fatalf("reload poll: %v", err)
+	}
+	if stored.Status != models.PollStatusClosed {
+		t.Fatalf("status %q, want %q", stored.Status, models.PollStatusClosed)
+	}
+
+	// The poll passed in is stale, as it would be for a vote racing the close
+	if _, err := castVote(poll, voter, VoteRequest{OptionID: poll.Options[0].ID}); err == nil {
+		t.Error("vote accepted after the poll closed")
+	}
+}
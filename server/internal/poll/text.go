@@ -0,0 +1,297 @@
+package poll
+
+import (
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"polling-app/internal/access"
+	"polling-app/internal/apperror"
+	"polling-app/internal/models"
+	"polling-app/internal/websocket"
+	"polling-app/pkg/database"
+)
+
+const (
+	// maxResponseLength bounds a free-text answer, in characters
+	maxResponseLength = 280
+
+	// maxWordCloudTerms is how many terms a word cloud reports
+	maxWordCloudTerms = 50
+)
+
+// stopWords are dropped from word clouds
+var stopWords = map[string]bool{
+	"a": true, "about": true, "all": true, "also": true, "am": true, "an": true, "and": true,
+	"any": true, "are": true, "as": true, "at": true, "be": true, "been": true, "but": true,
+	"by": true, "can": true, "could": true, "do": true, "does": true, "for": true, "from": true,
+	"had": true, "has": true, "have": true, "he": true, "her": true, "his": true, "how": true,
+	"i": true, "if": true, "in": true, "into": true, "is": true, "it": true, "its": true,
+	"just": true, "me": true, "more": true, "my": true, "no": true, "not": true, "of": true,
+	"on": true, "or": true, "our": true, "so": true, "some": true, "than": true, "that": true,
+	"the": true, "their": true, "them": true, "then": true, "there": true, "these": true,
+	"they": true, "this": true, "to": true, "too": true, "us": true, "very": true, "was": true,
+	"we": true, "were": true, "what": true, "when": true, "which": true, "who": true,
+	"will": true, "with": true, "would": true, "you": true, "your": true,
+}
+
+type TermCount struct {
+	Term  string `json:"term"`
+	Count int    `json:"count"`
+}
+
+// TextResponse is a free-text answer as shown to the room, without its author
+type TextResponse struct {
+	ID        uint      `json:"id"`
+	PollID    uint      `json:"poll_id"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ModerateRequest struct {
+	Action string `json:"action" binding:"required,oneof=approve hide"`
+}
+
+// cleanResponse trims a free-text answer, collapses its whitespace and checks
+// its length
+func cleanResponse(text string) (string, error) {
+	text = strings.Join(strings.Fields(text), " ")
+	if text == "" {
+		return "", apperror.New(http.StatusBadRequest, "text is required")
+	}
+	if len([]rune(text)) > maxResponseLength {
+		return "", apperror.New(http.StatusBadRequest, "Answer is too long")
+	}
+	return text, nil
+}
+
+// normalizeTerms splits text into lower-case words, stripping punctuation
+// and dropping stop words and single characters
+func normalizeTerms(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '\''
+	})
+
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		word = strings.Trim(word, "'")
+		if len([]rune(word)) < 2 || stopWords[word] {
+			continue
+		}
+		terms = append(terms, word)
+	}
+	return terms
+}
+
+// buildWordCloud counts term frequencies across answers, most frequent first
+func buildWordCloud(texts []string) []TermCount {
+	counts := make(map[string]int)
+	for _, text := range texts {
+		for _, term := range normalizeTerms(text) {
+			counts[term]++
+		}
+	}
+
+	cloud := make([]TermCount, 0, len(counts))
+	for term, count := range counts {
+		cloud = append(cloud, TermCount{Term: term, Count: count})
+	}
+	sort.Slice(cloud, func(i, j int) bool {
+		if cloud[i].Count != cloud[j].Count {
+			return cloud[i].Count > cloud[j].Count
+		}
+		return cloud[i].Term < cloud[j].Term
+	})
+
+	if len(cloud) > maxWordCloudTerms {
+		cloud = cloud[:maxWordCloudTerms]
+	}
+	return cloud
+}
+
+// approvedResponses returns the answers of a text poll the room may see
+func approvedResponses(pollID uint) ([]TextResponse, error) {
+	var responses []TextResponse
+	err := database.DB.Model(&models.Vote{}).
+		Select("id, poll_id, text, created_at").
+		Where("poll_id = ? AND moderation = ?", pollID, models.ModerationApproved).
+		Order("created_at ASC").
+		Scan(&responses).Error
+	return responses, err
+}
+
+func wordCloudFor(responses []TextResponse) []TermCount {
+	texts := make([]string, len(responses))
+	for i, response := range responses {
+		texts[i] = response.Text
+	}
+	return buildWordCloud(texts)
+}
+
+// publishResponse broadcasts an approved answer. The word cloud follows in
+// the next results update.
+func publishResponse(poll *models.Poll, vote *models.Vote) {
+	websocket.BroadcastToRoom(poll.RoomID, websocket.EventTextResponse, TextResponse{
+		ID:        vote.ID,
+		PollID:    vote.PollID,
+		Text:      vote.Text,
+		CreatedAt: vote.CreatedAt,
+	})
+}
+
+// queueResponse pushes an answer awaiting approval to the room's moderators
+func queueResponse(poll *models.Poll, vote *models.Vote) {
+	moderators, err := access.MembersWith(poll.RoomID, access.Moderate)
+	if err != nil {
+		log.Printf("Failed to load moderators of room %s: %v", poll.RoomID, err)
+		return
+	}
+
+	websocket.SendToUsers(poll.RoomID, moderators, websocket.EventPendingResponse, TextResponse{
+		ID:        vote.ID,
+		PollID:    vote.PollID,
+		Text:      vote.Text,
+		CreatedAt: vote.CreatedAt,
+	})
+}
+
+// withdrawResponse tells the room to drop a previously published answer
+func withdrawResponse(poll *models.Poll, vote *models.Vote) {
+	websocket.BroadcastToRoom(poll.RoomID, websocket.EventResponseHidden, gin.H{
+		"id":      vote.ID,
+		"poll_id": vote.PollID,
+	})
+}
+
+// moderateResponse approves or hides a free-text answer. Approving publishes
+// it to the room; hiding an already published answer withdraws it.
+func moderateResponse(poll *models.Poll, user models.User, responseID uint, action string) (*models.Vote, error) {
+	if err := access.Require(poll.RoomID, user.ID, access.Moderate); err != nil {
+		return nil, err
+	}
+
+	if poll.Type != models.PollTypeText {
+		return nil, apperror.New(http.StatusBadRequest, "Poll does not take text answers")
+	}
+
+	var vote models.Vote
+	if err := database.DB.First(&vote, "id = ? AND poll_id = ?", responseID, poll.ID).Error; err != nil {
+		return nil, apperror.New(http.StatusNotFound, "Answer not found")
+	}
+
+	status := models.ModerationApproved
+	if action == "hide" {
+		status = models.ModerationHidden
+	}
+	if vote.Moderation == status {
+		return &vote, nil
+	}
+
+	wasApproved := vote.Moderation == models.ModerationApproved
+	if err := database.DB.Model(&vote).Update("moderation", status).Error; err != nil {
+		return nil, apperror.New(http.StatusInternalServerError, "Failed to moderate answer")
+	}
+
+	switch {
+	case status == models.ModerationApproved:
+		publishResponse(poll, &vote)
+		queueResultsUpdate(poll.ID)
+	case wasApproved:
+		withdrawResponse(poll, &vote)
+		queueResultsUpdate(poll.ID)
+	}
+
+	return &vote, nil
+}
+
+// GetResponses lists a text poll's answers for moderation. Moderators see
+// every answer and may filter by ?status=; everyone else only sees approved
+// ones.
+func GetResponses(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+	currentUser := user.(models.User)
+
+	poll, err := loadPoll(c.Param("id"), "")
+	if err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	if err := access.Require(poll.RoomID, currentUser.ID, access.View); err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	if poll.Type != models.PollTypeText {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Poll does not take text answers"})
+		return
+	}
+
+	if !access.Can(poll.RoomID, currentUser.ID, access.Moderate) {
+		responses, err := approvedResponses(poll.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load answers"})
+			return
+		}
+		c.JSON(http.StatusOK, responses)
+		return
+	}
+
+	query := database.DB.Preload("User").Where("poll_id = ?", poll.ID).Order("created_at ASC")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("moderation = ?", status)
+	}
+
+	var votes []models.Vote
+	if err := query.Find(&votes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load answers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, votes)
+}
+
+// ModerateResponse approves or hides one answer of a text poll
+func ModerateResponse(c *gin.Context) {
+	var req ModerateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+	currentUser := user.(models.User)
+
+	poll, err := loadPoll(c.Param("id"), "")
+	if err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	responseID, err := strconv.ParseUint(c.Param("responseId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid answer ID"})
+		return
+	}
+
+	vote, err := moderateResponse(poll, currentUser, uint(responseID), req.Action)
+	if err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, vote)
+}
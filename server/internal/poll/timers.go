@@ -0,0 +1,123 @@
+package poll
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"polling-app/internal/apperror"
+	"polling-app/internal/models"
+	"polling-app/internal/scheduler"
+	"polling-app/pkg/database"
+)
+
+// defaultSweepInterval is used when POLL_SWEEP_INTERVAL is unset or invalid
+const defaultSweepInterval = 5 * time.Second
+
+// StartTimers re-arms the timers of all live and scheduled polls and starts
+// the sweep that catches overdue ones, along with the loop that pushes live
+// results. It must run after the database is initialised.
+func StartTimers() {
+	var polls []models.Poll
+	err := database.DB.
+		Where("status IN ?", []string{models.PollStatusLive, models.PollStatusScheduled}).
+		Find(&polls).Error
+	if err != nil {
+		log.Printf("Failed to load pending polls: %v", err)
+	}
+
+	for i := range polls {
+		if polls[i].Status == models.PollStatusScheduled {
+			scheduleStart(&polls[i])
+		} else {
+			scheduleEnd(&polls[i])
+		}
+	}
+	log.Printf("Re-armed %d poll timers", len(polls))
+
+	scheduler.Every(sweepInterval(), "poll-sweep", sweepPolls)
+	startResultsBroadcast()
+}
+
+// scheduleEnd arms the timer that closes the poll at its EndTime
+func scheduleEnd(poll *models.Poll) {
+	pollID := poll.ID
+	scheduler.Schedule(endTimerKey(pollID), poll.EndTime, func() {
+		expirePoll(pollID)
+	})
+}
+
+// scheduleStart arms the timer that opens a scheduled poll at ScheduledAt
+func scheduleStart(poll *models.Poll) {
+	if poll.ScheduledAt == nil {
+		return
+	}
+	pollID := poll.ID
+	scheduler.Schedule(startTimerKey(pollID), *poll.ScheduledAt, func() {
+		openScheduledPoll(pollID)
+	})
+}
+
+// openScheduledPoll opens a poll whose scheduled start has been reached.
+// Losing the race to the sweep or to the host is not an error.
+func openScheduledPoll(pollID uint) {
+	poll, err := loadPoll(pollID, "")
+	if err != nil {
+		return
+	}
+
+	if poll.Status != models.PollStatusScheduled || poll.ScheduledAt == nil || poll.ScheduledAt.After(time.Now()) {
+		return
+	}
+
+	if err := openPoll(poll); err != nil && apperror.StatusOf(err) != http.StatusConflict {
+		log.Printf("Failed to open scheduled poll %d: %v", pollID, err)
+	}
+}
+
+// sweepPolls opens scheduled polls and closes live polls whose time has come.
+// It covers timers lost in a restart or that fired late.
+func sweepPolls() {
+	now := time.Now()
+
+	var startIDs []uint
+	err := database.DB.Model(&models.Poll{}).
+		Where("status = ? AND scheduled_at <= ?", models.PollStatusScheduled, now).
+		Pluck("id", &startIDs).Error
+	if err != nil {
+		log.Printf("Failed to sweep scheduled polls: %v", err)
+	}
+	for _, pollID := range startIDs {
+		openScheduledPoll(pollID)
+	}
+
+	var endIDs []uint
+	err = database.DB.Model(&models.Poll{}).
+		Where("status = ? AND end_time <= ?", models.PollStatusLive, now).
+		Pluck("id", &endIDs).Error
+	if err != nil {
+		log.Printf("Failed to sweep overdue polls: %v", err)
+	}
+	for _, pollID := range endIDs {
+		expirePoll(pollID)
+	}
+}
+
+func endTimerKey(pollID uint) string {
+	return fmt.Sprintf("poll:%d:end", pollID)
+}
+
+func startTimerKey(pollID uint) string {
+	return fmt.Sprintf("poll:%d:start", pollID)
+}
+
+func sweepInterval() time.Duration {
+	if value := os.Getenv("POLL_SWEEP_INTERVAL"); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed > 0 {
+			return parsed
+		}
+	}
+	return defaultSweepInterval
+}
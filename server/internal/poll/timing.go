@@ -0,0 +1,78 @@
+package poll
+
+import (
+	"math"
+	"os"
+	"sync"
+	"time"
+
+	"polling-app/internal/models"
+)
+
+// defaultLatencyMargin is used when VOTE_LATENCY_MARGIN is unset or invalid
+const defaultLatencyMargin = 750 * time.Millisecond
+
+// deliveries records when start_poll was written to each participant's
+// socket, keyed by poll and then user. Only the first delivery counts.
+var deliveries = make(map[uint]map[uint]time.Time)
+var deliveriesMu sync.Mutex
+
+func recordDelivery(pollID, userID uint, at time.Time) {
+	deliveriesMu.Lock()
+	defer deliveriesMu.Unlock()
+
+	users, ok := deliveries[pollID]
+	if !ok {
+		users = make(map[uint]time.Time)
+		deliveries[pollID] = users
+	}
+	if _, seen := users[userID]; !seen {
+		users[userID] = at
+	}
+}
+
+func deliveredAt(pollID, userID uint) (time.Time, bool) {
+	deliveriesMu.Lock()
+	defer deliveriesMu.Unlock()
+
+	at, ok := deliveries[pollID][userID]
+	return at, ok
+}
+
+func forgetDeliveries(pollID uint) {
+	deliveriesMu.Lock()
+	defer deliveriesMu.Unlock()
+	delete(deliveries, pollID)
+}
+
+// measureAnswerTime works out how long the user took to answer. The server
+// time runs from start_poll reaching the user's socket (or from the poll's
+// start if it never did, e.g. after a restart or for REST-only clients) to
+// now, minus any time the poll spent paused. The client's claim is only
+// honoured within a bounded latency margin below that, so it can account for
+// network delay but never make an answer look faster than it was.
+func measureAnswerTime(poll *models.Poll, userID uint, claimed float64, now time.Time) (adjusted, server float64) {
+	start, ok := deliveredAt(poll.ID, userID)
+	if !ok {
+		start = poll.StartTime
+	}
+
+	server = math.Max(now.Sub(start).Seconds()-poll.PausedTotal, 0)
+
+	adjusted = server
+	if claimed > 0 {
+		floor := math.Max(server-latencyMargin().Seconds(), 0)
+		adjusted = math.Min(math.Max(claimed, floor), server)
+	}
+
+	return adjusted, server
+}
+
+func latencyMargin() time.Duration {
+	if value := os.Getenv("VOTE_LATENCY_MARGIN"); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed >= 0 {
+			return parsed
+		}
+	}
+	return defaultLatencyMargin
+}
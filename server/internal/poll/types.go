@@ -0,0 +1,211 @@
+package poll
+
+import (
+	"net/http"
+
+	"polling-app/internal/apperror"
+	"polling-app/internal/models"
+	"polling-app/pkg/database"
+)
+
+// configurePoll applies the type-specific settings of a create request to the
+// poll and returns the options to create with it
+func configurePoll(poll *models.Poll, req CreatePollRequest) ([]models.Option, error) {
+	poll.Type = req.Type
+	if poll.Type == "" {
+		poll.Type = models.PollTypeSingle
+	}
+
+	if poll.Type == models.PollTypeText {
+		if len(req.Options) > 0 || req.CorrectID != 0 || len(req.CorrectIDs) > 0 {
+			return nil, apperror.New(http.StatusBadRequest, "Text polls take no options")
+		}
+		if poll.Mode == models.PollModeQuiz {
+			return nil, apperror.New(http.StatusBadRequest, "Text polls cannot be quizzes")
+		}
+		poll.MinChoices = 0
+		poll.MaxChoices = 0
+		poll.Moderated = req.Moderated == nil || *req.Moderated
+		return nil, nil
+	}
+
+	if poll.Type == models.PollTypeNumeric {
+		return nil, configureNumeric(poll, req)
+	}
+
+	if len(req.Options) < 2 {
+		return nil, apperror.New(http.StatusBadRequest, "At least two options are required")
+	}
+
+	correct := make(map[uint]bool)
+	switch poll.Type {
+	case models.PollTypeSingle:
+		if len(req.Options) > 4 {
+			return nil, apperror.New(http.StatusBadRequest, "Single choice polls allow at most four options")
+		}
+		if req.CorrectID == 0 || int(req.CorrectID) > len(req.Options) {
+			return nil, apperror.New(http.StatusBadRequest, "correct_id must refer to one of the options")
+		}
+		correct[req.CorrectID] = true
+		poll.MinChoices = 1
+		poll.MaxChoices = 1
+
+	case models.PollTypeMultiple, models.PollTypeRanked:
+		if poll.Type == models.PollTypeRanked && (poll.Mode == models.PollModeQuiz || len(req.CorrectIDs) > 0) {
+			return nil, apperror.New(http.StatusBadRequest, "Ranked polls have no correct answers")
+		}
+		for _, id := range req.CorrectIDs {
+			if id == 0 || int(id) > len(req.Options) {
+				return nil, apperror.New(http.StatusBadRequest, "correct_ids must refer to the options")
+			}
+			correct[id] = true
+		}
+		if poll.Mode == models.PollModeQuiz && len(correct) == 0 {
+			return nil, apperror.New(http.StatusBadRequest, "Quiz polls need at least one correct option")
+		}
+
+		poll.MinChoices = req.MinChoices
+		if poll.MinChoices == 0 {
+			poll.MinChoices = 1
+		}
+		poll.MaxChoices = req.MaxChoices
+		if poll.MaxChoices == 0 {
+			poll.MaxChoices = len(req.Options)
+		}
+		if poll.MinChoices > poll.MaxChoices || poll.MaxChoices > len(req.Options) {
+			return nil, apperror.New(http.StatusBadRequest, "Choice limits must satisfy 1 <= min_choices <= max_choices <= number of options")
+		}
+	}
+
+	options := make([]models.Option, len(req.Options))
+	for i, optionText := range req.Options {
+		options[i] = models.Option{
+			Text:      optionText,
+			IsCorrect: correct[uint(i+1)],
+		}
+	}
+
+	return options, nil
+}
+
+// fillBallot validates the answer in req against the poll's type and fills
+// in the answer fields of vote. It returns the credit the answer earns.
+func fillBallot(poll *models.Poll, req VoteRequest, vote *models.Vote) (float64, error) {
+	if poll.Type == models.PollTypeText {
+		text, err := cleanResponse(req.Text)
+		if err != nil {
+			return 0, err
+		}
+		vote.Text = text
+		vote.Moderation = models.ModerationApproved
+		if poll.Moderated {
+			vote.Moderation = models.ModerationPending
+		}
+		return 0, nil
+	}
+
+	if poll.Type == models.PollTypeNumeric {
+		credit, err := checkNumericAnswer(poll, req.Value)
+		if err != nil {
+			return 0, err
+		}
+		value := *req.Value
+		vote.Value = &value
+		return credit, nil
+	}
+
+	selections, chosen, err := buildSelections(poll, req)
+	if err != nil {
+		return 0, err
+	}
+	vote.Selections = selections
+	if poll.Type == models.PollTypeSingle {
+		optionID := req.OptionID
+		vote.OptionID = &optionID
+	}
+
+	return answerCredit(poll, chosen)
+}
+
+// buildSelections validates the options chosen on a ballot against the poll
+// and returns the selections to store with the vote
+func buildSelections(poll *models.Poll, req VoteRequest) ([]models.Selection, []models.Option, error) {
+	optionIDs := req.OptionIDs
+	if poll.Type == models.PollTypeSingle {
+		if req.OptionID == 0 {
+			return nil, nil, apperror.New(http.StatusBadRequest, "option_id is required")
+		}
+		optionIDs = []uint{req.OptionID}
+	}
+
+	if len(optionIDs) < poll.MinChoices || len(optionIDs) > poll.MaxChoices {
+		return nil, nil, apperror.New(http.StatusBadRequest, "Number of selected options is out of range")
+	}
+
+	seen := make(map[uint]bool, len(optionIDs))
+	for _, id := range optionIDs {
+		if seen[id] {
+			return nil, nil, apperror.New(http.StatusBadRequest, "Options may only be selected once")
+		}
+		seen[id] = true
+	}
+
+	var chosen []models.Option
+	if err := database.DB.Where("poll_id = ? AND id IN ?", poll.ID, optionIDs).Find(&chosen).Error; err != nil {
+		return nil, nil, apperror.New(http.StatusInternalServerError, "Failed to load options")
+	}
+	if len(chosen) != len(optionIDs) {
+		return nil, nil, apperror.New(http.StatusBadRequest, "Option does not belong to this poll")
+	}
+
+	selections := make([]models.Selection, len(optionIDs))
+	for i, id := range optionIDs {
+		selections[i] = models.Selection{
+			PollID:   poll.ID,
+			OptionID: id,
+		}
+		if poll.Type == models.PollTypeRanked {
+			selections[i].Rank = i + 1
+		}
+	}
+
+	return selections, chosen, nil
+}
+
+// answerCredit returns the share of full marks a ballot earns. Single choice
+// answers are right or wrong; multiple choice answers earn partial credit,
+// one step per correct option picked and lose one per wrong option picked.
+func answerCredit(poll *models.Poll, chosen []models.Option) (float64, error) {
+	if poll.Type == models.PollTypeRanked {
+		return 0, nil
+	}
+
+	if poll.Type == models.PollTypeSingle {
+		if len(chosen) == 1 && chosen[0].IsCorrect {
+			return 1, nil
+		}
+		return 0, nil
+	}
+
+	var totalCorrect int64
+	if err := database.DB.Model(&models.Option{}).Where("poll_id = ? AND is_correct = ?", poll.ID, true).Count(&totalCorrect).Error; err != nil {
+		return 0, apperror.New(http.StatusInternalServerError, "Failed to score answer")
+	}
+	if totalCorrect == 0 {
+		return 0, nil
+	}
+
+	hits := 0
+	for _, option := range chosen {
+		if option.IsCorrect {
+			hits++
+		} else {
+			hits--
+		}
+	}
+	if hits <= 0 {
+		return 0, nil
+	}
+
+	return float64(hits) / float64(totalCorrect), nil
+}
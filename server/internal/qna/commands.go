@@ -0,0 +1,72 @@
+package qna
+
+import (
+	"encoding/json"
+
+	"polling-app/internal/websocket"
+)
+
+type askCommand struct {
+	AskQuestionRequest
+}
+
+type upvoteCommand struct {
+	QuestionID uint `json:"question_id" binding:"required"`
+}
+
+type moderateCommand struct {
+	QuestionID uint `json:"question_id" binding:"required"`
+	ModerateQuestionRequest
+}
+
+// RegisterCommands installs the Q&A commands on the WebSocket hub
+func RegisterCommands() {
+	websocket.RegisterCommand(websocket.CommandAskQuestion, handleAskCommand)
+	websocket.RegisterCommand(websocket.CommandUpvoteQuestion, upvoteCommandHandler(true))
+	websocket.RegisterCommand(websocket.CommandRemoveUpvote, upvoteCommandHandler(false))
+	websocket.RegisterCommand(websocket.CommandModerateQuestion, handleModerateCommand)
+}
+
+func handleAskCommand(client *websocket.Client, payload json.RawMessage) (interface{}, error) {
+	var cmd askCommand
+	if err := websocket.DecodePayload(payload, &cmd); err != nil {
+		return nil, err
+	}
+
+	room, err := loadRoom(client.RoomID)
+	if err != nil {
+		return nil, err
+	}
+
+	return askQuestion(room, client.User, cmd.AskQuestionRequest)
+}
+
+func upvoteCommandHandler(upvote bool) websocket.CommandHandler {
+	return func(client *websocket.Client, payload json.RawMessage) (interface{}, error) {
+		var cmd upvoteCommand
+		if err := websocket.DecodePayload(payload, &cmd); err != nil {
+			return nil, err
+		}
+
+		question, err := loadQuestion(cmd.QuestionID, client.RoomID)
+		if err != nil {
+			return nil, err
+		}
+
+		return setUpvote(question, client.User, upvote)
+	}
+}
+
+func handleModerateCommand(client *websocket.Client, payload json.RawMessage) (interface{}, error) {
+	var cmd moderateCommand
+	if err := websocket.DecodePayload(payload, &cmd); err != nil {
+		return nil, err
+	}
+
+	question, err := loadQuestion(cmd.QuestionID, client.RoomID)
+	if err != nil {
+		return nil, err
+	}
+
+	return moderateQuestion(question, client.User, cmd.Action)
+}
@@ -0,0 +1,168 @@
+package qna
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"polling-app/internal/access"
+	"polling-app/internal/apperror"
+	"polling-app/internal/models"
+	"polling-app/pkg/database"
+)
+
+type AskQuestionRequest struct {
+	Text      string `json:"text" binding:"required"`
+	Anonymous bool   `json:"anonymous"`
+}
+
+type ModerateQuestionRequest struct {
+	Action string `json:"action" binding:"required,oneof=pin unpin answer unanswer hide unhide"`
+}
+
+// currentUser returns the authenticated user, responding with 401 when absent
+func currentUser(c *gin.Context) (models.User, bool) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return models.User{}, false
+	}
+	return user.(models.User), true
+}
+
+// GetQuestions lists a room's Q&A board in feed order. Moderators also see
+// hidden questions; every viewer learns which questions they have upvoted.
+func GetQuestions(c *gin.Context) {
+	viewer, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	room, err := loadRoom(c.Param("id"))
+	if err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	if err := access.Require(room.ID, viewer.ID, access.View); err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	canModerate := access.Can(room.ID, viewer.ID, access.Moderate)
+	questions, err := listQuestions(room.ID, canModerate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load questions"})
+		return
+	}
+
+	upvoted := make(map[uint]bool)
+	if len(questions) > 0 {
+		ids := make([]uint, len(questions))
+		for i, question := range questions {
+			ids[i] = question.ID
+		}
+
+		var upvotedIDs []uint
+		database.DB.Model(&models.QuestionUpvote{}).
+			Where("user_id = ? AND question_id IN ?", viewer.ID, ids).
+			Pluck("question_id", &upvotedIDs)
+		for _, id := range upvotedIDs {
+			upvoted[id] = true
+		}
+	}
+
+	views := make([]QuestionView, len(questions))
+	for i := range questions {
+		views[i] = newQuestionView(&questions[i])
+		views[i].Upvoted = upvoted[questions[i].ID]
+	}
+
+	c.JSON(http.StatusOK, views)
+}
+
+// AskQuestion posts a question to a room's Q&A board
+func AskQuestion(c *gin.Context) {
+	var req AskQuestionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	room, err := loadRoom(c.Param("id"))
+	if err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	question, err := askQuestion(room, user, req)
+	if err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, question)
+}
+
+// UpvoteQuestion adds the user's upvote to a question
+func UpvoteQuestion(c *gin.Context) {
+	upvoteHandler(c, true)
+}
+
+// RemoveUpvote withdraws the user's upvote from a question
+func RemoveUpvote(c *gin.Context) {
+	upvoteHandler(c, false)
+}
+
+func upvoteHandler(c *gin.Context, upvote bool) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	question, err := loadQuestion(c.Param("id"), "")
+	if err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	view, err := setUpvote(question, user, upvote)
+	if err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, view)
+}
+
+// ModerateQuestion pins, marks answered or hides a question, or undoes it
+func ModerateQuestion(c *gin.Context) {
+	var req ModerateQuestionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	question, err := loadQuestion(c.Param("id"), "")
+	if err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	view, err := moderateQuestion(question, user, req.Action)
+	if err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, view)
+}
@@ -0,0 +1,66 @@
+package qna
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"polling-app/internal/models"
+	"polling-app/internal/testdb"
+	"polling-app/pkg/database"
+)
+
+// getQuestions requests the room's Q&A board as user, or anonymously when
+// user is nil
+func getQuestions(roomID string, user *models.User) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/rooms/:id/questions", func(c *gin.Context) {
+		if user != nil {
+			c.Set("user", *user)
+		}
+	}, GetQuestions)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/rooms/"+roomID+"/questions", nil))
+	return recorder
+}
+
+func TestGetQuestionsRequiresLogin(t *testing.T) {
+	if code := getQuestions("any-room", nil).Code; code != http.StatusUnauthorized {
+		t.Errorf("status %d, want %d", code, http.StatusUnauthorized)
+	}
+}
+
+func TestGetQuestionsRejectsNonMembers(t *testing.T) {
+	testdb.Open(t)
+
+	host := models.User{Email: "host@example.com", Password: "x", Name: "Host"}
+	outsider := models.User{Email: "outsider@example.com", Password: "x", Name: "Outsider"}
+	for _, user := range []*models.User{&host, &outsider} {
+		if err := database.DB.Create(user).Error; err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+
+	room := models.Room{Name: "Test", HostID: host.ID}
+	if err := database.DB.Create(&room).Error; err != nil {
+		t.Fatalf("create room: %v", err)
+	}
+	member := models.RoomParticipant{RoomID: room.ID, UserID: host.ID, Role: models.RoleOwner}
+	if err := database.DB.Create(&member).Error; err != nil {
+		t.Fatalf("add host: %v", err)
+	}
+	question := models.Question{RoomID: room.ID, AuthorID: host.ID, Text: "Anything?"}
+	if err := database.DB.Create(&question).Error; err != nil {
+		t.Fatalf("ask question: %v", err)
+	}
+
+	if code := getQuestions(room.ID, &outsider).Code; code != http.StatusForbidden {
+		t.Errorf("non-member got status %d, want %d", code, http.StatusForbidden)
+	}
+	if code := getQuestions(room.ID, &host).Code; code != http.StatusOK {
+		t.Errorf("member got status %d, want %d", code, http.StatusOK)
+	}
+}
@@ -0,0 +1,259 @@
+package qna
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"polling-app/internal/access"
+	"polling-app/internal/apperror"
+	"polling-app/internal/models"
+	"polling-app/internal/websocket"
+	"polling-app/pkg/database"
+)
+
+// maxQuestionLength bounds a question, in characters
+const maxQuestionLength = 500
+
+// QuestionView is a question as shown to the room. Author is omitted for
+// anonymous questions; Upvoted is only set when listing for a single viewer.
+type QuestionView struct {
+	ID        uint      `json:"id"`
+	RoomID    string    `json:"room_id"`
+	Text      string    `json:"text"`
+	Anonymous bool      `json:"anonymous"`
+	Author    *Author   `json:"author,omitempty"`
+	Upvotes   int       `json:"upvotes"`
+	Upvoted   bool      `json:"upvoted,omitempty"`
+	Pinned    bool      `json:"pinned"`
+	Answered  bool      `json:"answered"`
+	Hidden    bool      `json:"hidden"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Author struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+// FeedEvent is the sorted list of visible questions broadcast to the room
+type FeedEvent struct {
+	RoomID    string         `json:"room_id"`
+	Questions []QuestionView `json:"questions"`
+}
+
+func newQuestionView(question *models.Question) QuestionView {
+	view := QuestionView{
+		ID:        question.ID,
+		RoomID:    question.RoomID,
+		Text:      question.Text,
+		Anonymous: question.Anonymous,
+		Upvotes:   question.Upvotes,
+		Pinned:    question.Pinned,
+		Answered:  question.Answered,
+		Hidden:    question.Hidden,
+		CreatedAt: question.CreatedAt,
+	}
+	if !question.Anonymous {
+		view.Author = &Author{ID: question.Author.ID, Name: question.Author.Name}
+	}
+	return view
+}
+
+// sortFeed orders questions pinned first, then open before answered, then by
+// upvotes, with older questions winning ties
+func sortFeed(questions []models.Question) {
+	sort.SliceStable(questions, func(i, j int) bool {
+		a, b := questions[i], questions[j]
+		if a.Pinned != b.Pinned {
+			return a.Pinned
+		}
+		if a.Answered != b.Answered {
+			return !a.Answered
+		}
+		if a.Upvotes != b.Upvotes {
+			return a.Upvotes > b.Upvotes
+		}
+		return a.CreatedAt.Before(b.CreatedAt)
+	})
+}
+
+func loadRoom(roomID string) (*models.Room, error) {
+	var room models.Room
+	if err := database.DB.First(&room, "id = ?", roomID).Error; err != nil {
+		return nil, apperror.New(http.StatusNotFound, "Room not found")
+	}
+	return &room, nil
+}
+
+// loadQuestion fetches a question by ID. When roomID is set the question must
+// belong to that room, which keeps WebSocket commands scoped to the client's room.
+func loadQuestion(questionID interface{}, roomID string) (*models.Question, error) {
+	var question models.Question
+	if err := database.DB.Preload("Author").First(&question, "id = ?", questionID).Error; err != nil {
+		return nil, apperror.New(http.StatusNotFound, "Question not found")
+	}
+
+	if roomID != "" && question.RoomID != roomID {
+		return nil, apperror.New(http.StatusNotFound, "Question not found")
+	}
+
+	return &question, nil
+}
+
+// listQuestions returns a room's questions in feed order. Hidden questions
+// are only included when includeHidden is set.
+func listQuestions(roomID string, includeHidden bool) ([]models.Question, error) {
+	query := database.DB.Preload("Author").Where("room_id = ?", roomID)
+	if !includeHidden {
+		query = query.Where("hidden = ?", false)
+	}
+
+	var questions []models.Question
+	if err := query.Find(&questions).Error; err != nil {
+		return nil, err
+	}
+
+	sortFeed(questions)
+	return questions, nil
+}
+
+// broadcastFeed pushes the room's sorted, visible questions to every client
+func broadcastFeed(roomID string) {
+	questions, err := listQuestions(roomID, false)
+	if err != nil {
+		return
+	}
+
+	views := make([]QuestionView, len(questions))
+	for i := range questions {
+		views[i] = newQuestionView(&questions[i])
+	}
+
+	websocket.BroadcastToRoom(roomID, websocket.EventQuestions, FeedEvent{
+		RoomID:    roomID,
+		Questions: views,
+	})
+}
+
+// askQuestion adds a question to the room's board and broadcasts the feed
+func askQuestion(room *models.Room, user models.User, req AskQuestionRequest) (*QuestionView, error) {
+	if err := access.Require(room.ID, user.ID, access.Participate); err != nil {
+		return nil, err
+	}
+
+	text := strings.Join(strings.Fields(req.Text), " ")
+	if text == "" {
+		return nil, apperror.New(http.StatusBadRequest, "text is required")
+	}
+	if len([]rune(text)) > maxQuestionLength {
+		return nil, apperror.New(http.StatusBadRequest, "Question is too long")
+	}
+
+	question := models.Question{
+		RoomID:    room.ID,
+		AuthorID:  user.ID,
+		Author:    user,
+		Anonymous: req.Anonymous,
+		Text:      text,
+	}
+	if err := database.DB.Omit(clause.Associations).Create(&question).Error; err != nil {
+		return nil, apperror.New(http.StatusInternalServerError, "Failed to save question")
+	}
+
+	broadcastFeed(room.ID)
+
+	view := newQuestionView(&question)
+	return &view, nil
+}
+
+// setUpvote adds or withdraws the user's upvote. The upvote row and the
+// denormalised counter change in one transaction; repeating either action is
+// a no-op.
+func setUpvote(question *models.Question, user models.User, upvote bool) (*QuestionView, error) {
+	if question.Hidden {
+		return nil, apperror.New(http.StatusNotFound, "Question not found")
+	}
+
+	room, err := loadRoom(question.RoomID)
+	if err != nil {
+		return nil, err
+	}
+	if err := access.Require(room.ID, user.ID, access.Participate); err != nil {
+		return nil, err
+	}
+
+	changed := false
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var result *gorm.DB
+		delta := 1
+		if upvote {
+			result = tx.Clauses(clause.OnConflict{DoNothing: true}).
+				Create(&models.QuestionUpvote{QuestionID: question.ID, UserID: user.ID})
+		} else {
+			delta = -1
+			result = tx.Where("question_id = ? AND user_id = ?", question.ID, user.ID).
+				Delete(&models.QuestionUpvote{})
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		changed = true
+		return tx.Model(question).UpdateColumn("upvotes", gorm.Expr("upvotes + ?", delta)).Error
+	})
+	if err != nil {
+		return nil, apperror.New(http.StatusInternalServerError, "Failed to record upvote")
+	}
+
+	if err := database.DB.Select("upvotes").First(question).Error; err != nil {
+		return nil, apperror.New(http.StatusInternalServerError, "Failed to load question")
+	}
+
+	if changed {
+		broadcastFeed(question.RoomID)
+	}
+
+	view := newQuestionView(question)
+	view.Upvoted = upvote
+	return &view, nil
+}
+
+// moderateQuestion applies a moderator action to a question and broadcasts the feed
+func moderateQuestion(question *models.Question, user models.User, action string) (*QuestionView, error) {
+	room, err := loadRoom(question.RoomID)
+	if err != nil {
+		return nil, err
+	}
+	if err := access.Require(room.ID, user.ID, access.Moderate); err != nil {
+		return nil, err
+	}
+
+	var column string
+	var value bool
+	switch action {
+	case "pin", "unpin":
+		column, value = "pinned", action == "pin"
+	case "answer", "unanswer":
+		column, value = "answered", action == "answer"
+	case "hide", "unhide":
+		column, value = "hidden", action == "hide"
+	default:
+		return nil, apperror.New(http.StatusBadRequest, "Unknown action")
+	}
+
+	if err := database.DB.Model(question).Update(column, value).Error; err != nil {
+		return nil, apperror.New(http.StatusInternalServerError, "Failed to moderate question")
+	}
+
+	broadcastFeed(question.RoomID)
+
+	view := newQuestionView(question)
+	return &view, nil
+}
@@ -0,0 +1,258 @@
+package room
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"polling-app/internal/access"
+	"polling-app/internal/apperror"
+	"polling-app/internal/models"
+	"polling-app/internal/websocket"
+	"polling-app/pkg/database"
+)
+
+type CapacityRequest struct {
+	MaxParticipants int `json:"max_participants" binding:"min=0"`
+}
+
+// Capacity is the live seat count of a room as shown to its moderators
+type Capacity struct {
+	RoomID          string `json:"room_id"`
+	MaxParticipants int    `json:"max_participants"` // 0 means unlimited
+	Seated          int64  `json:"seated"`
+	Waitlisted      int64  `json:"waitlisted"`
+	Connected       int    `json:"connected"` // Audience members with at least one open WebSocket
+}
+
+// freeSeats returns how many seats the room has left, or -1 if it has no
+// limit. It locks the room row, so concurrent joins count seats one at a time.
+func freeSeats(tx *gorm.DB, roomID string) (int, error) {
+	var room models.Room
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&room, "id = ?", roomID).Error; err != nil {
+		return 0, err
+	}
+	if room.MaxParticipants == 0 {
+		return -1, nil
+	}
+
+	seated, err := seatsTaken(tx, roomID)
+	if err != nil {
+		return 0, err
+	}
+	if free := room.MaxParticipants - int(seated); free > 0 {
+		return free, nil
+	}
+	return 0, nil
+}
+
+// seatsTaken counts the room's audience; staff do not take up seats
+func seatsTaken(db *gorm.DB, roomID string) (int64, error) {
+	var seated int64
+	err := db.Model(&models.RoomParticipant{}).
+		Where("room_id = ? AND role IN ?", roomID, models.AudienceRoles).
+		Count(&seated).Error
+	return seated, err
+}
+
+// admitWaitlist lets waitlisted users in, in the order they arrived, for as
+// long as the room has free seats. It is called whenever seats may have freed
+// up.
+func admitWaitlist(roomID string) {
+	admitted := 0
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		free, err := freeSeats(tx, roomID)
+		if err != nil || free == 0 {
+			return err
+		}
+
+		query := tx.Where("room_id = ? AND status = ?", roomID, models.JoinRequestWaitlisted).Order("id ASC")
+		if free > 0 {
+			query = query.Limit(free)
+		}
+		var queue []models.JoinRequest
+		if err := query.Find(&queue).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for _, request := range queue {
+			err := tx.Model(&request).Updates(map[string]interface{}{
+				"status":     models.JoinRequestApproved,
+				"decided_at": now,
+			}).Error
+			if err != nil {
+				return err
+			}
+			err = addMember(tx.Clauses(clause.OnConflict{DoNothing: true}), roomID, request.UserID, models.RoleParticipant, request.InviteID)
+			if err != nil {
+				return err
+			}
+			admitted++
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Failed to admit waitlist of room %s: %v", roomID, err)
+		return
+	}
+
+	if admitted > 0 {
+		broadcastCapacity(roomID)
+	}
+}
+
+// loadCapacity counts the room's seats, waitlist and connected audience
+func loadCapacity(roomID string) (*Capacity, error) {
+	var room models.Room
+	if err := database.DB.Select("id", "max_participants").First(&room, "id = ?", roomID).Error; err != nil {
+		return nil, err
+	}
+
+	seated, err := seatsTaken(database.DB, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	var waitlisted int64
+	err = database.DB.Model(&models.JoinRequest{}).
+		Where("room_id = ? AND status = ?", roomID, models.JoinRequestWaitlisted).
+		Count(&waitlisted).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &Capacity{
+		RoomID:          roomID,
+		MaxParticipants: room.MaxParticipants,
+		Seated:          seated,
+		Waitlisted:      waitlisted,
+		Connected:       websocket.AudienceConnections(roomID),
+	}, nil
+}
+
+// broadcastCapacity pushes the room's capacity to the moderators connected to it
+func broadcastCapacity(roomID string) {
+	capacity, err := loadCapacity(roomID)
+	if err != nil {
+		log.Printf("Failed to load capacity of room %s: %v", roomID, err)
+		return
+	}
+
+	moderators, err := access.MembersWith(roomID, access.Moderate)
+	if err != nil {
+		log.Printf("Failed to load moderators of room %s: %v", roomID, err)
+		return
+	}
+
+	websocket.SendToUsers(roomID, moderators, websocket.EventCapacity, capacity)
+}
+
+// GetCapacity returns the room's live seat count for moderators
+func GetCapacity(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+
+	roomID := c.Param("id")
+	if err := access.Require(roomID, user.(models.User).ID, access.Moderate); err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	capacity, err := loadCapacity(roomID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load capacity"})
+		return
+	}
+
+	c.JSON(http.StatusOK, capacity)
+}
+
+// UpdateCapacity sets how many audience members the room seats. Lowering it
+// below the current count keeps everyone in and waitlists new joiners; raising
+// it admits waitlisted users straight away.
+func UpdateCapacity(c *gin.Context) {
+	var req CapacityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+
+	roomID := c.Param("id")
+	if err := access.Require(roomID, user.(models.User).ID, access.ManageJoinPolicy); err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	err := database.DB.Model(&models.Room{}).
+		Where("id = ?", roomID).
+		Update("max_participants", req.MaxParticipants).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update capacity"})
+		return
+	}
+
+	admitWaitlist(roomID)
+	broadcastCapacity(roomID)
+
+	capacity, err := loadCapacity(roomID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load capacity"})
+		return
+	}
+
+	c.JSON(http.StatusOK, capacity)
+}
+
+// LeaveRoom lets a member give up their seat. The owner must hand the room
+// over first.
+func LeaveRoom(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+	currentUser := user.(models.User)
+
+	roomID := c.Param("id")
+	role, err := access.RoleOf(roomID, currentUser.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check membership"})
+		return
+	}
+	if role == "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Not a participant in this room"})
+		return
+	}
+	if role == models.RoleOwner {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Transfer the room before leaving it"})
+		return
+	}
+
+	err = database.DB.Where("room_id = ? AND user_id = ?", roomID, currentUser.ID).Delete(&models.RoomParticipant{}).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to leave room"})
+		return
+	}
+
+	removed := MemberRemovedEvent{RoomID: roomID, UserID: currentUser.ID}
+	websocket.DisconnectUser(roomID, currentUser.ID, websocket.EventMemberRemoved, removed)
+	websocket.BroadcastToRoom(roomID, websocket.EventMemberRemoved, removed)
+
+	admitWaitlist(roomID)
+	broadcastCapacity(roomID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Left room"})
+}
@@ -0,0 +1,107 @@
+package room
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"polling-app/internal/apperror"
+	"polling-app/internal/auth"
+	"polling-app/internal/models"
+	"polling-app/pkg/database"
+)
+
+type GuestJoinRequest struct {
+	InviteCode string `json:"invite_code" binding:"required"`
+	Name       string `json:"name" binding:"required,max=50"`
+	DeviceID   string `json:"device_id" binding:"max=100"` // Random ID the client keeps across visits
+	Password   string `json:"password"`                    // Needed when the room uses the password policy
+}
+
+type GuestJoinResponse struct {
+	Token     string      `json:"token"`
+	ExpiresAt time.Time   `json:"expires_at"`
+	User      models.User `json:"user"`
+	Room      models.Room `json:"room"`
+
+	// JoinRequest is set when the room needs approval or is full; the guest
+	// waits in the lobby until they are let in
+	JoinRequest *models.JoinRequest `json:"join_request,omitempty"`
+}
+
+// JoinAsGuest lets someone without an account join a room using only its
+// invite code and a display name. A guest user is created and added to the
+// room, and a short-lived guest token is returned for further requests. In
+// rooms that need approval or are full the guest is put in the lobby instead.
+func JoinAsGuest(c *gin.Context) {
+	var req GuestJoinRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Display name is required"})
+		return
+	}
+
+	invite, room, err := findInvite(req.InviteCode)
+	if err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	device, network := guestFingerprint(c, req.DeviceID)
+	if isBanned(room.ID, 0, device, network) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You are banned from this room"})
+		return
+	}
+
+	guest := models.User{
+		Name:               name,
+		IsGuest:            true,
+		Fingerprint:        device,
+		NetworkFingerprint: network,
+	}
+
+	if err := checkJoinPolicy(room, guest, req.Password); err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	var request *models.JoinRequest
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&guest).Error; err != nil {
+			return err
+		}
+		request, err = admitOrQueue(tx, room, guest.ID, invite)
+		return err
+	})
+	if err != nil {
+		respondJoinError(c, err)
+		return
+	}
+
+	announceJoin(room.ID, request)
+	status := http.StatusCreated
+	if request != nil {
+		status = http.StatusAccepted
+	}
+
+	token, expiresAt, err := auth.GenerateGuestToken(guest)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	c.JSON(status, GuestJoinResponse{
+		Token:       token,
+		ExpiresAt:   expiresAt,
+		User:        guest,
+		Room:        *room,
+		JoinRequest: request,
+	})
+}
@@ -0,0 +1,89 @@
+package room
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"polling-app/internal/models"
+	"polling-app/internal/testdb"
+	"polling-app/pkg/database"
+)
+
+func TestGuestBanMatchesDeviceNotNetwork(t *testing.T) {
+	testdb.Open(t)
+	t.Setenv("JWT_SECRET", "test")
+
+	host := models.User{Email: "host@example.com", Password: "x", Name: "Host"}
+	if err := database.DB.Create(&host).Error; err != nil {
+		t.Fatalf("create host: %v", err)
+	}
+	room := models.Room{Name: "All hands", HostID: host.ID}
+	if err := database.DB.Create(&room).Error; err != nil {
+		t.Fatalf("create room: %v", err)
+	}
+	if err := addMember(database.DB, room.ID, host.ID, models.RoleOwner, nil); err != nil {
+		t.Fatalf("add host: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/guests/join", JoinAsGuest)
+
+	// Every guest joins from the same address with the same browser
+	join := func(name, deviceID string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(GuestJoinRequest{InviteCode: room.InviteCode, Name: name, DeviceID: deviceID})
+		request := httptest.NewRequest(http.MethodPost, "/guests/join", bytes.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("User-Agent", "Office Browser 1.0")
+		request.RemoteAddr = "203.0.113.7:4000"
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	first := join("Heckler", "device-a")
+	if first.Code != http.StatusCreated {
+		t.Fatalf("first guest: status %d, want %d", first.Code, http.StatusCreated)
+	}
+	var joined GuestJoinResponse
+	if err := json.Unmarshal(first.Body.Bytes(), &joined); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+
+	var banned models.User
+	if err := database.DB.First(&banned, joined.User.ID).Error; err != nil {
+		t.Fatalf("load guest: %v", err)
+	}
+	ban := models.RoomBan{
+		RoomID:             room.ID,
+		UserID:             banned.ID,
+		Fingerprint:        banned.Fingerprint,
+		NetworkFingerprint: banned.NetworkFingerprint,
+		BannedByID:         host.ID,
+	}
+	if err := database.DB.Create(&ban).Error; err != nil {
+		t.Fatalf("ban guest: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		deviceID string
+		want     int
+	}{
+		{"another device on the same network", "device-b", http.StatusCreated},
+		{"the banned device", "device-a", http.StatusForbidden},
+		{"no device ID on the banned network", "", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := join("Guest", tt.deviceID).Code; code != tt.want {
+				t.Errorf("status %d, want %d", code, tt.want)
+			}
+		})
+	}
+}
@@ -4,16 +4,21 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"polling-app/internal/access"
+	"polling-app/internal/apperror"
 	"polling-app/internal/models"
 	"polling-app/pkg/database"
 )
 
 type CreateRoomRequest struct {
-	Name string `json:"name" binding:"required"`
+	Name            string `json:"name" binding:"required"`
+	MaxParticipants int    `json:"max_participants" binding:"omitempty,min=0"`
 }
 
 type JoinRoomRequest struct {
 	InviteCode string `json:"invite_code" binding:"required"`
+	Password   string `json:"password"` // Needed when the room uses the password policy
 }
 
 func CreateRoom(c *gin.Context) {
@@ -33,8 +38,9 @@ func CreateRoom(c *gin.Context) {
 
 	// Create new room
 	room := models.Room{
-		Name:   req.Name,
-		HostID: currentUser.ID,
+		Name:            req.Name,
+		HostID:          currentUser.ID,
+		MaxParticipants: req.MaxParticipants,
 	}
 
 	if err := database.DB.Create(&room).Error; err != nil {
@@ -42,8 +48,8 @@ func CreateRoom(c *gin.Context) {
 		return
 	}
 
-	// Add host as first participant
-	if err := database.DB.Model(&room).Association("Participants").Append(&currentUser); err != nil {
+	// Add host as first participant, owning the room
+	if err := addMember(database.DB, room.ID, currentUser.ID, models.RoleOwner, nil); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add host to participants"})
 		return
 	}
@@ -52,7 +58,17 @@ func CreateRoom(c *gin.Context) {
 }
 
 func GetRoom(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
 	roomID := c.Param("id")
+	if err := access.Require(roomID, user.(models.User).ID, access.View); err != nil {
+		apperror.Respond(c, err)
+		return
+	}
 
 	var room models.Room
 	if err := database.DB.Preload("Host").Preload("Participants").First(&room, "id = ?", roomID).Error; err != nil {
@@ -78,30 +94,62 @@ func JoinRoom(c *gin.Context) {
 	}
 	currentUser := user.(models.User)
 
-	var room models.Room
-	if err := database.DB.First(&room, "invite_code = ?", req.InviteCode).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Room not found"})
+	invite, room, err := findInvite(req.InviteCode)
+	if err != nil {
+		apperror.Respond(c, err)
 		return
 	}
 
-	if !room.IsActive {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Room is not active"})
+	if isBanned(room.ID, currentUser.ID, currentUser.Fingerprint, currentUser.NetworkFingerprint) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You are banned from this room"})
 		return
 	}
 
 	// Check if user is already a participant
-	var count int64
-	database.DB.Model(&room).Where("user_id = ?", currentUser.ID).Association("Participants").Count(&count)
-	if count > 0 {
+	if isParticipant(room, currentUser.ID) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Already a participant in this room"})
 		return
 	}
 
-	// Add user to participants
-	if err := database.DB.Model(&room).Association("Participants").Append(&currentUser); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to join room"})
+	if err := checkJoinPolicy(room, currentUser, req.Password); err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	// Add user to participants, or to the lobby when the room needs approval
+	// or is full
+	var request *models.JoinRequest
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		request, err = admitOrQueue(tx, room, currentUser.ID, invite)
+		return err
+	})
+	if err != nil {
+		respondJoinError(c, err)
+		return
+	}
+
+	announceJoin(room.ID, request)
+	if request != nil {
+		c.JSON(http.StatusAccepted, request)
 		return
 	}
 
 	c.JSON(http.StatusOK, room)
-} 
\ No newline at end of file
+}
+
+// isParticipant reports whether the user is in the room's participant list
+func isParticipant(room *models.Room, userID uint) bool {
+	role, err := access.RoleOf(room.ID, userID)
+	return err == nil && role != ""
+}
+
+// addMember adds a user to the room's participant list with a role, noting
+// the invite they joined through
+func addMember(db *gorm.DB, roomID string, userID uint, role string, inviteID *uint) error {
+	return db.Create(&models.RoomParticipant{
+		RoomID:   roomID,
+		UserID:   userID,
+		Role:     role,
+		InviteID: inviteID,
+	}).Error
+}
@@ -0,0 +1,257 @@
+package room
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"polling-app/internal/access"
+	"polling-app/internal/apperror"
+	"polling-app/internal/models"
+	"polling-app/pkg/database"
+)
+
+type CreateInviteRequest struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	MaxUses   int        `json:"max_uses" binding:"omitempty,min=0"`
+}
+
+// UpdateInviteRequest changes an invite's limits. A null expires_at removes
+// the expiry; max_uses of 0 removes the cap.
+type UpdateInviteRequest struct {
+	ExpiresAt *time.Time `json:"expires_at"`
+	MaxUses   int        `json:"max_uses" binding:"omitempty,min=0"`
+}
+
+// findInvite resolves an invite code to a usable invite and its open room
+func findInvite(code string) (*models.RoomInvite, *models.Room, error) {
+	code = strings.TrimSpace(code)
+
+	// Codes are upper case, but older rooms have lower-case hex codes
+	var invite models.RoomInvite
+	err := database.DB.
+		Where("code IN ? AND revoked_at IS NULL", []string{code, strings.ToUpper(code)}).
+		First(&invite).Error
+	if err != nil {
+		return nil, nil, apperror.New(http.StatusNotFound, "Room not found")
+	}
+
+	if invite.ExpiresAt != nil && !invite.ExpiresAt.After(time.Now()) {
+		return nil, nil, apperror.New(http.StatusGone, "Invite has expired")
+	}
+
+	var room models.Room
+	if err := database.DB.First(&room, "id = ?", invite.RoomID).Error; err != nil {
+		return nil, nil, apperror.New(http.StatusNotFound, "Room not found")
+	}
+
+	if !room.IsActive {
+		return nil, nil, apperror.New(http.StatusBadRequest, "Room is not active")
+	}
+
+	return &invite, &room, nil
+}
+
+// redeemInvite counts one use against an invite. The conditional update keeps
+// concurrent joins from going past MaxUses.
+func redeemInvite(tx *gorm.DB, invite *models.RoomInvite) error {
+	result := tx.Model(&models.RoomInvite{}).
+		Where("id = ? AND revoked_at IS NULL AND (max_uses = 0 OR uses < max_uses)", invite.ID).
+		UpdateColumn("uses", gorm.Expr("uses + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return apperror.New(http.StatusGone, "Invite is no longer valid")
+	}
+	return nil
+}
+
+// respondJoinError reports a failed join, keeping service errors as they are
+func respondJoinError(c *gin.Context, err error) {
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) {
+		apperror.Respond(c, err)
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to join room"})
+}
+
+// ListInvites lists a room's invite links with how often each was used
+func ListInvites(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+
+	roomID := c.Param("id")
+	if err := access.Require(roomID, user.(models.User).ID, access.ManageInvites); err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	var invites []models.RoomInvite
+	if err := database.DB.Where("room_id = ?", roomID).Order("created_at ASC").Find(&invites).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load invites"})
+		return
+	}
+
+	c.JSON(http.StatusOK, invites)
+}
+
+// CreateInvite issues a named invite link for the room
+func CreateInvite(c *gin.Context) {
+	var req CreateInviteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+	currentUser := user.(models.User)
+
+	roomID := c.Param("id")
+	if err := access.Require(roomID, currentUser.ID, access.ManageInvites); err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Expiry must be in the future"})
+		return
+	}
+
+	code, err := models.GenerateInviteCode(database.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate invite code"})
+		return
+	}
+
+	invite := models.RoomInvite{
+		RoomID:      roomID,
+		Code:        code,
+		Name:        strings.TrimSpace(req.Name),
+		ExpiresAt:   req.ExpiresAt,
+		MaxUses:     req.MaxUses,
+		CreatedByID: currentUser.ID,
+	}
+	if err := database.DB.Create(&invite).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invite"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, invite)
+}
+
+// UpdateInvite sets an invite's expiry and maximum number of uses
+func UpdateInvite(c *gin.Context) {
+	var req UpdateInviteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	invite, ok := loadInvite(c)
+	if !ok {
+		return
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Expiry must be in the future"})
+		return
+	}
+
+	err := database.DB.Model(invite).Updates(map[string]interface{}{
+		"expires_at": req.ExpiresAt,
+		"max_uses":   req.MaxUses,
+	}).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update invite"})
+		return
+	}
+
+	c.JSON(http.StatusOK, invite)
+}
+
+// RotateInvite replaces an invite's code. The old code stops working at once;
+// the invite keeps its name, limits and use count.
+func RotateInvite(c *gin.Context) {
+	invite, ok := loadInvite(c)
+	if !ok {
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		code, err := models.GenerateInviteCode(tx)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(invite).Update("code", code).Error; err != nil {
+			return err
+		}
+		if invite.IsDefault {
+			return tx.Model(&models.Room{}).Where("id = ?", invite.RoomID).Update("invite_code", code).Error
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate invite"})
+		return
+	}
+
+	c.JSON(http.StatusOK, invite)
+}
+
+// RevokeInvite disables a named invite link. The default invite cannot be
+// revoked; rotate it instead.
+func RevokeInvite(c *gin.Context) {
+	invite, ok := loadInvite(c)
+	if !ok {
+		return
+	}
+
+	if invite.IsDefault {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The default invite can only be rotated"})
+		return
+	}
+
+	if err := database.DB.Model(invite).Update("revoked_at", time.Now()).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke invite"})
+		return
+	}
+
+	c.JSON(http.StatusOK, invite)
+}
+
+// loadInvite fetches the invite in the URL after checking the user may manage
+// the room's invites, responding with an error when either fails
+func loadInvite(c *gin.Context) (*models.RoomInvite, bool) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return nil, false
+	}
+
+	roomID := c.Param("id")
+	if err := access.Require(roomID, user.(models.User).ID, access.ManageInvites); err != nil {
+		apperror.Respond(c, err)
+		return nil, false
+	}
+
+	var invite models.RoomInvite
+	if err := database.DB.First(&invite, "id = ? AND room_id = ?", c.Param("inviteId"), roomID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invite not found"})
+		return nil, false
+	}
+
+	return &invite, true
+}
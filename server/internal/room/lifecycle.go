@@ -0,0 +1,223 @@
+package room
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"polling-app/internal/access"
+	"polling-app/internal/apperror"
+	"polling-app/internal/models"
+	"polling-app/internal/poll"
+	"polling-app/internal/scheduler"
+	"polling-app/internal/websocket"
+	"polling-app/pkg/database"
+)
+
+// defaultPurgeInterval is used when ROOM_PURGE_INTERVAL is unset or invalid
+const defaultPurgeInterval = time.Hour
+
+// RoomClosedEvent is the last frame clients receive when their room closes
+type RoomClosedEvent struct {
+	RoomID string `json:"room_id"`
+	Status string `json:"status"`
+}
+
+// CloseRoom stops a room: running polls end, scheduled polls return to
+// drafts and every socket is disconnected. Results stay readable and the
+// room can be reopened.
+func CloseRoom(c *gin.Context) {
+	transitionRoom(c, access.CloseRoom, []string{models.RoomStatusOpen}, models.RoomStatusClosed)
+}
+
+// ReopenRoom opens a closed room again
+func ReopenRoom(c *gin.Context) {
+	transitionRoom(c, access.CloseRoom, []string{models.RoomStatusClosed}, models.RoomStatusOpen)
+}
+
+// ArchiveRoom closes a room for good, keeping its results read-only
+func ArchiveRoom(c *gin.Context) {
+	transitionRoom(c, access.DeleteRoom, []string{models.RoomStatusOpen, models.RoomStatusClosed}, models.RoomStatusArchived)
+}
+
+func transitionRoom(c *gin.Context, perm access.Permission, from []string, to string) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+
+	roomID := c.Param("id")
+	if err := access.Require(roomID, user.(models.User).ID, perm); err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":    to,
+		"is_active": to == models.RoomStatusOpen,
+	}
+	switch to {
+	case models.RoomStatusOpen:
+		updates["closed_at"] = nil
+	case models.RoomStatusClosed:
+		updates["closed_at"] = now
+	case models.RoomStatusArchived:
+		updates["archived_at"] = now
+	}
+
+	// The conditional update makes concurrent transitions apply only once
+	result := database.DB.Model(&models.Room{}).
+		Where("id = ? AND status IN ?", roomID, from).
+		Updates(updates)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update room"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Room cannot be " + to + " from its current state"})
+		return
+	}
+
+	if to != models.RoomStatusOpen {
+		shutDownRoom(roomID, to)
+	}
+
+	var room models.Room
+	if err := database.DB.First(&room, "id = ?", roomID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Room not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, room)
+}
+
+// shutDownRoom ends the room's polls and disconnects its sockets
+func shutDownRoom(roomID, status string) {
+	poll.EndRoomPolls(roomID)
+	websocket.DisconnectRoom(roomID, websocket.EventRoomClosed, RoomClosedEvent{
+		RoomID: roomID,
+		Status: status,
+	})
+}
+
+// DeleteRoom removes a room. With a retention period configured the room is
+// hidden at once and its data purged once the period is over; otherwise the
+// room and everything in it are purged immediately.
+func DeleteRoom(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+
+	roomID := c.Param("id")
+	if err := access.Require(roomID, user.(models.User).ID, access.DeleteRoom); err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	err := database.DB.Model(&models.Room{}).Where("id = ?", roomID).Updates(map[string]interface{}{
+		"status":    models.RoomStatusClosed,
+		"is_active": false,
+	}).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete room"})
+		return
+	}
+	shutDownRoom(roomID, "deleted")
+
+	if retentionPeriod() > 0 {
+		err = database.DB.Delete(&models.Room{}, "id = ?", roomID).Error
+	} else {
+		err = purgeRoom(roomID)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete room"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Room deleted"})
+}
+
+// purgeRoom permanently deletes a room with its polls, ballots, questions,
+// members, bans, invites, join requests and tickets
+func purgeRoom(roomID string) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		pollIDs := tx.Model(&models.Poll{}).Select("id").Where("room_id = ?", roomID)
+		questionIDs := tx.Model(&models.Question{}).Select("id").Where("room_id = ?", roomID)
+
+		// Children go before their parents; the first failure stops the purge
+		var err error
+		purge := func(model interface{}, query string, args ...interface{}) {
+			if err == nil {
+				err = tx.Unscoped().Where(query, args...).Delete(model).Error
+			}
+		}
+
+		purge(&models.Selection{}, "poll_id IN (?)", pollIDs)
+		purge(&models.VoteRevision{}, "poll_id IN (?)", pollIDs)
+		purge(&models.VoteReceipt{}, "poll_id IN (?)", pollIDs)
+		purge(&models.Vote{}, "poll_id IN (?)", pollIDs)
+		purge(&models.Option{}, "poll_id IN (?)", pollIDs)
+		purge(&models.Poll{}, "room_id = ?", roomID)
+		purge(&models.QuestionUpvote{}, "question_id IN (?)", questionIDs)
+		purge(&models.Question{}, "room_id = ?", roomID)
+		purge(&models.RoomParticipant{}, "room_id = ?", roomID)
+		purge(&models.RoomBan{}, "room_id = ?", roomID)
+		purge(&models.JoinRequest{}, "room_id = ?", roomID)
+		purge(&models.RoomInvite{}, "room_id = ?", roomID)
+		purge(&models.WebSocketTicket{}, "room_id = ?", roomID)
+		purge(&models.Room{}, "id = ?", roomID)
+		return err
+	})
+}
+
+// StartRetentionSweep starts the loop that purges deleted rooms whose
+// retention period is over. It must run after the database is initialised.
+func StartRetentionSweep() {
+	scheduler.Every(purgeInterval(), "room-purge", purgeExpiredRooms)
+}
+
+func purgeExpiredRooms() {
+	cutoff := time.Now().Add(-retentionPeriod())
+
+	var roomIDs []string
+	err := database.DB.Unscoped().Model(&models.Room{}).
+		Where("deleted_at IS NOT NULL AND deleted_at <= ?", cutoff).
+		Pluck("id", &roomIDs).Error
+	if err != nil {
+		log.Printf("Failed to find rooms to purge: %v", err)
+		return
+	}
+
+	for _, roomID := range roomIDs {
+		if err := purgeRoom(roomID); err != nil {
+			log.Printf("Failed to purge room %s: %v", roomID, err)
+		}
+	}
+}
+
+// retentionPeriod is how long a deleted room is kept before it is purged.
+// Zero, the default, purges rooms as soon as they are deleted.
+func retentionPeriod() time.Duration {
+	if value := os.Getenv("ROOM_RETENTION_PERIOD"); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed >= 0 {
+			return parsed
+		}
+	}
+	return 0
+}
+
+func purgeInterval() time.Duration {
+	if value := os.Getenv("ROOM_PURGE_INTERVAL"); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed > 0 {
+			return parsed
+		}
+	}
+	return defaultPurgeInterval
+}
@@ -0,0 +1,378 @@
+package room
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"polling-app/internal/access"
+	"polling-app/internal/apperror"
+	"polling-app/internal/models"
+	"polling-app/internal/websocket"
+	"polling-app/pkg/database"
+)
+
+// queuedStatuses are the statuses of requests still waiting in the lobby
+var queuedStatuses = []string{models.JoinRequestPending, models.JoinRequestWaitlisted}
+
+type JoinPolicyRequest struct {
+	Policy         string   `json:"policy" binding:"required,oneof=open password domain approval"`
+	Password       string   `json:"password" binding:"omitempty,min=4,max=72"`
+	AllowedDomains []string `json:"allowed_domains"`
+}
+
+// PendingJoin is a lobby entry as shown to the room's moderators
+type PendingJoin struct {
+	ID          uint      `json:"id"`
+	UserID      uint      `json:"user_id"`
+	Name        string    `json:"name"`
+	IsGuest     bool      `json:"is_guest"`
+	Invite      string    `json:"invite"`
+	RequestedAt time.Time `json:"requested_at"`
+}
+
+// checkJoinPolicy checks the password and domain policies. The approval policy
+// is handled by the caller, since it defers the join rather than refusing it.
+// The domain policy only trusts verified addresses: anyone can register with
+// an email they do not own, so only accounts whose email a provider such as
+// Google has confirmed may join.
+func checkJoinPolicy(room *models.Room, user models.User, password string) error {
+	switch room.JoinPolicy {
+	case models.JoinPolicyPassword:
+		if password == "" {
+			return apperror.New(http.StatusForbidden, "This room requires a password")
+		}
+		if !room.CheckJoinPassword(password) {
+			return apperror.New(http.StatusForbidden, "Incorrect room password")
+		}
+	case models.JoinPolicyDomain:
+		if user.IsGuest || !user.EmailVerified {
+			return apperror.New(http.StatusForbidden, "This room requires a verified email address; sign in with Google to join")
+		}
+		if !room.AllowsEmail(user.Email) {
+			return apperror.New(http.StatusForbidden, "This room is restricted to approved email domains")
+		}
+	}
+	return nil
+}
+
+// admitOrQueue adds the user to the room, or queues them when the room needs
+// approval or has no free seat. It returns the queued request, or nil once the
+// user is a member.
+func admitOrQueue(tx *gorm.DB, room *models.Room, userID uint, invite *models.RoomInvite) (*models.JoinRequest, error) {
+	if room.JoinPolicy == models.JoinPolicyApproval {
+		return queueJoin(tx, room.ID, userID, invite, models.JoinRequestPending)
+	}
+
+	free, err := freeSeats(tx, room.ID)
+	if err != nil {
+		return nil, err
+	}
+	if free == 0 {
+		return queueJoin(tx, room.ID, userID, invite, models.JoinRequestWaitlisted)
+	}
+
+	if err := redeemInvite(tx, invite); err != nil {
+		return nil, err
+	}
+	return nil, addMember(tx, room.ID, userID, models.RoleParticipant, &invite.ID)
+}
+
+// queueJoin puts the user in the room's lobby with the given status. An
+// existing queued request is returned as is; a new one counts a use against
+// the invite straight away, so links with a use limit cannot queue more people
+// than they admit.
+func queueJoin(tx *gorm.DB, roomID string, userID uint, invite *models.RoomInvite, status string) (*models.JoinRequest, error) {
+	var request models.JoinRequest
+	err := tx.Where("room_id = ? AND user_id = ? AND status IN ?", roomID, userID, queuedStatuses).
+		First(&request).Error
+	if err == nil {
+		return &request, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if err := redeemInvite(tx, invite); err != nil {
+		return nil, err
+	}
+
+	request = models.JoinRequest{
+		RoomID:   roomID,
+		UserID:   userID,
+		InviteID: &invite.ID,
+		Status:   status,
+	}
+	if err := tx.Create(&request).Error; err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// pendingJoins lists the room's lobby, longest waiting first
+func pendingJoins(roomID string) ([]PendingJoin, error) {
+	var queue []PendingJoin
+	err := database.DB.Table("join_requests").
+		Select("join_requests.id, users.id AS user_id, users.name, users.is_guest, room_invites.name AS invite, join_requests.created_at AS requested_at").
+		Joins("JOIN users ON users.id = join_requests.user_id").
+		Joins("LEFT JOIN room_invites ON room_invites.id = join_requests.invite_id").
+		Where("join_requests.room_id = ? AND join_requests.status = ?", roomID, models.JoinRequestPending).
+		Order("join_requests.created_at ASC").
+		Scan(&queue).Error
+	return queue, err
+}
+
+// announceJoin keeps the room's moderators up to date after a join attempt
+func announceJoin(roomID string, request *models.JoinRequest) {
+	if request != nil && request.Status == models.JoinRequestPending {
+		broadcastJoinQueue(roomID)
+	}
+	broadcastCapacity(roomID)
+}
+
+// broadcastJoinQueue pushes the room's lobby to the moderators connected to it
+func broadcastJoinQueue(roomID string) {
+	queue, err := pendingJoins(roomID)
+	if err != nil {
+		log.Printf("Failed to load join queue of room %s: %v", roomID, err)
+		return
+	}
+
+	moderators, err := access.MembersWith(roomID, access.Moderate)
+	if err != nil {
+		log.Printf("Failed to load moderators of room %s: %v", roomID, err)
+		return
+	}
+
+	websocket.SendToUsers(roomID, moderators, websocket.EventJoinQueue, queue)
+}
+
+// UpdateJoinPolicy sets who may join the room. The password is kept when
+// switching back to the password policy without giving a new one.
+func UpdateJoinPolicy(c *gin.Context) {
+	var req JoinPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+
+	roomID := c.Param("id")
+	if err := access.Require(roomID, user.(models.User).ID, access.ManageJoinPolicy); err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	var room models.Room
+	if err := database.DB.First(&room, "id = ?", roomID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Room not found"})
+		return
+	}
+
+	if req.Password != "" {
+		if err := room.SetJoinPassword(req.Password); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set room password"})
+			return
+		}
+	}
+	if req.Policy == models.JoinPolicyPassword && room.JoinPassword == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A password is required for this policy"})
+		return
+	}
+
+	if req.AllowedDomains != nil {
+		domains, err := normalizeDomains(req.AllowedDomains)
+		if err != nil {
+			apperror.Respond(c, err)
+			return
+		}
+		room.AllowedDomains = strings.Join(domains, ",")
+	}
+	if req.Policy == models.JoinPolicyDomain && room.AllowedDomains == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one email domain is required for this policy"})
+		return
+	}
+
+	room.JoinPolicy = req.Policy
+	err := database.DB.Model(&room).Updates(map[string]interface{}{
+		"join_policy":     room.JoinPolicy,
+		"join_password":   room.JoinPassword,
+		"allowed_domains": room.AllowedDomains,
+	}).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update join policy"})
+		return
+	}
+
+	c.JSON(http.StatusOK, room)
+}
+
+// normalizeDomains lower-cases the domains, strips a leading @ and drops
+// duplicates
+func normalizeDomains(domains []string) ([]string, error) {
+	seen := make(map[string]bool)
+	normalized := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		domain = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(domain), "@"))
+		if domain == "" {
+			continue
+		}
+		if strings.ContainsAny(domain, "@, ") || !strings.Contains(domain, ".") {
+			return nil, apperror.New(http.StatusBadRequest, "Invalid email domain: "+domain)
+		}
+		if !seen[domain] {
+			seen[domain] = true
+			normalized = append(normalized, domain)
+		}
+	}
+	return normalized, nil
+}
+
+// ListJoinRequests returns the room's lobby for moderators
+func ListJoinRequests(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+
+	roomID := c.Param("id")
+	if err := access.Require(roomID, user.(models.User).ID, access.Moderate); err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	queue, err := pendingJoins(roomID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load join requests"})
+		return
+	}
+
+	c.JSON(http.StatusOK, queue)
+}
+
+// GetJoinRequest returns the caller's latest request to join the room, so a
+// user waiting in the lobby can tell when they have been let in
+func GetJoinRequest(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	var request models.JoinRequest
+	err := database.DB.Where("room_id = ? AND user_id = ?", c.Param("id"), user.(models.User).ID).
+		Order("created_at DESC").
+		First(&request).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Join request not found"})
+		return
+	}
+
+	if request.Status == models.JoinRequestWaitlisted {
+		var ahead int64
+		err := database.DB.Model(&models.JoinRequest{}).
+			Where("room_id = ? AND status = ? AND id < ?", request.RoomID, models.JoinRequestWaitlisted, request.ID).
+			Count(&ahead).Error
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load waitlist"})
+			return
+		}
+		request.Position = int(ahead) + 1
+	}
+
+	c.JSON(http.StatusOK, request)
+}
+
+// ApproveJoinRequest admits a user waiting in the lobby
+func ApproveJoinRequest(c *gin.Context) {
+	decideJoinRequest(c, models.JoinRequestApproved)
+}
+
+// DenyJoinRequest turns away a user waiting in the lobby
+func DenyJoinRequest(c *gin.Context) {
+	decideJoinRequest(c, models.JoinRequestDenied)
+}
+
+// decideJoinRequest settles a pending request. The conditional update lets
+// two moderators race on the same request without admitting the user twice.
+// Approving a request while the room is full moves it to the waitlist.
+func decideJoinRequest(c *gin.Context, status string) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+	currentUser := user.(models.User)
+
+	roomID := c.Param("id")
+	if err := access.Require(roomID, currentUser.ID, access.Moderate); err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	var request models.JoinRequest
+	if err := database.DB.First(&request, "id = ? AND room_id = ?", c.Param("requestId"), roomID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Join request not found"})
+		return
+	}
+
+	now := time.Now()
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if status == models.JoinRequestApproved {
+			free, err := freeSeats(tx, roomID)
+			if err != nil {
+				return err
+			}
+			if free == 0 {
+				status = models.JoinRequestWaitlisted
+			}
+		}
+
+		result := tx.Model(&models.JoinRequest{}).
+			Where("id = ?", request.ID).
+			Where("status = ?", models.JoinRequestPending).
+			Updates(map[string]interface{}{
+				"status":        status,
+				"decided_by_id": currentUser.ID,
+				"decided_at":    now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return apperror.New(http.StatusConflict, "Join request was already decided")
+		}
+		request.Status = status
+		request.DecidedByID = &currentUser.ID
+		request.DecidedAt = &now
+
+		if status != models.JoinRequestApproved {
+			return nil
+		}
+		return addMember(tx.Clauses(clause.OnConflict{DoNothing: true}), roomID, request.UserID, models.RoleParticipant, request.InviteID)
+	})
+	if err != nil {
+		var appErr *apperror.Error
+		if errors.As(err, &appErr) {
+			apperror.Respond(c, err)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decide join request"})
+		return
+	}
+
+	broadcastJoinQueue(roomID)
+	broadcastCapacity(roomID)
+	c.JSON(http.StatusOK, request)
+}
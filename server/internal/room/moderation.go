@@ -0,0 +1,251 @@
+package room
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"polling-app/internal/access"
+	"polling-app/internal/apperror"
+	"polling-app/internal/models"
+	"polling-app/internal/websocket"
+	"polling-app/pkg/database"
+)
+
+type RemoveMemberRequest struct {
+	Reason string `json:"reason" binding:"max=200"`
+}
+
+// KickedEvent is the last frame a removed member receives
+type KickedEvent struct {
+	RoomID string `json:"room_id"`
+	Reason string `json:"reason,omitempty"`
+	Banned bool   `json:"banned"`
+}
+
+type MemberRemovedEvent struct {
+	RoomID string `json:"room_id"`
+	UserID uint   `json:"user_id"`
+}
+
+type MemberMutedEvent struct {
+	RoomID string `json:"room_id"`
+	UserID uint   `json:"user_id"`
+	Muted  bool   `json:"muted"`
+}
+
+// guestFingerprint identifies the device a guest joins from by the random
+// device ID clients keep in local storage, and their network by their address
+// and user agent. The device fingerprint is empty when no ID was sent.
+func guestFingerprint(c *gin.Context, deviceID string) (device, network string) {
+	network = hashFingerprint(c.ClientIP() + "|" + c.Request.UserAgent())
+	if deviceID = strings.TrimSpace(deviceID); deviceID != "" {
+		device = hashFingerprint(deviceID)
+	}
+	return device, network
+}
+
+func hashFingerprint(source string) string {
+	sum := sha256.Sum256([]byte(source))
+	return hex.EncodeToString(sum[:])
+}
+
+// isBanned reports whether the user is banned from the room. Guests are also
+// matched by device, or by network when they have no device ID. A guest with a
+// device ID is never matched by network: a whole audience can share one
+// address and browser build behind a NAT or proxy.
+func isBanned(roomID string, userID uint, device, network string) bool {
+	query := database.DB.Model(&models.RoomBan{}).Where("room_id = ?", roomID)
+	switch {
+	case device != "":
+		query = query.Where("user_id = ? OR fingerprint = ?", userID, device)
+	case network != "":
+		query = query.Where("user_id = ? OR network_fingerprint = ?", userID, network)
+	default:
+		query = query.Where("user_id = ?", userID)
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		// Fail closed: a ban that cannot be checked is treated as a ban
+		return true
+	}
+	return count > 0
+}
+
+// KickMember removes a member from the room and disconnects them. They may
+// join again with the invite code.
+func KickMember(c *gin.Context) {
+	removeMember(c, false)
+}
+
+// BanMember removes a member from the room and keeps them from rejoining,
+// including as a new guest from the same device
+func BanMember(c *gin.Context) {
+	removeMember(c, true)
+}
+
+func removeMember(c *gin.Context, ban bool) {
+	var req RemoveMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	actor, roomID, targetID, ok := memberAction(c)
+	if !ok {
+		return
+	}
+
+	if _, err := access.RequireOutrank(roomID, actor.ID, targetID, access.Moderate); err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if ban {
+			var target models.User
+			if err := tx.First(&target, targetID).Error; err != nil {
+				return err
+			}
+			record := models.RoomBan{
+				RoomID:             roomID,
+				UserID:             targetID,
+				Fingerprint:        target.Fingerprint,
+				NetworkFingerprint: target.NetworkFingerprint,
+				Reason:             req.Reason,
+				BannedByID:         actor.ID,
+			}
+			if err := tx.Create(&record).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Where("room_id = ? AND user_id = ?", roomID, targetID).Delete(&models.RoomParticipant{}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove member"})
+		return
+	}
+
+	websocket.DisconnectUser(roomID, targetID, websocket.EventKicked, KickedEvent{
+		RoomID: roomID,
+		Reason: req.Reason,
+		Banned: ban,
+	})
+	websocket.BroadcastToRoom(roomID, websocket.EventMemberRemoved, MemberRemovedEvent{
+		RoomID: roomID,
+		UserID: targetID,
+	})
+
+	admitWaitlist(roomID)
+	broadcastCapacity(roomID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed"})
+}
+
+// MuteMember stops a member from voting and posting while they keep watching
+func MuteMember(c *gin.Context) {
+	setMuted(c, true)
+}
+
+// UnmuteMember lets a muted member take part again
+func UnmuteMember(c *gin.Context) {
+	setMuted(c, false)
+}
+
+func setMuted(c *gin.Context, muted bool) {
+	actor, roomID, targetID, ok := memberAction(c)
+	if !ok {
+		return
+	}
+
+	target, err := access.RequireOutrank(roomID, actor.ID, targetID, access.Moderate)
+	if err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	if err := database.DB.Model(target).Update("muted", muted).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update member"})
+		return
+	}
+
+	event := MemberMutedEvent{RoomID: roomID, UserID: targetID, Muted: muted}
+	websocket.BroadcastToRoom(roomID, websocket.EventMemberMuted, event)
+
+	c.JSON(http.StatusOK, event)
+}
+
+// ListBans lists the bans of a room, newest first
+func ListBans(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+
+	roomID := c.Param("id")
+	if err := access.Require(roomID, user.(models.User).ID, access.Moderate); err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	var bans []models.RoomBan
+	if err := database.DB.Where("room_id = ?", roomID).Order("created_at DESC").Find(&bans).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load bans"})
+		return
+	}
+
+	c.JSON(http.StatusOK, bans)
+}
+
+// LiftBan removes a ban so the user may join the room again
+func LiftBan(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+
+	roomID := c.Param("id")
+	if err := access.Require(roomID, user.(models.User).ID, access.Moderate); err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	result := database.DB.Where("id = ? AND room_id = ?", c.Param("banId"), roomID).Delete(&models.RoomBan{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to lift ban"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ban not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Ban lifted"})
+}
+
+// memberAction reads the acting user and the target member from the request,
+// responding with an error when either is missing
+func memberAction(c *gin.Context) (models.User, string, uint, bool) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return models.User{}, "", 0, false
+	}
+
+	targetID, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return models.User{}, "", 0, false
+	}
+
+	return user.(models.User), c.Param("id"), uint(targetID), true
+}
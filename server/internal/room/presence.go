@@ -0,0 +1,111 @@
+package room
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"polling-app/internal/access"
+	"polling-app/internal/apperror"
+	"polling-app/internal/models"
+	"polling-app/internal/websocket"
+	"polling-app/pkg/database"
+)
+
+// PresenceSummary lists who is connected to a room and, while a poll is
+// running, how many of the connected audience have answered it
+type PresenceSummary struct {
+	Connected []websocket.Presence `json:"connected"`
+	Poll      *PollTurnout         `json:"poll,omitempty"`
+}
+
+// PollTurnout compares the connected audience with those who answered
+type PollTurnout struct {
+	PollID      uint `json:"poll_id"`
+	Connected   int  `json:"connected"`
+	Answered    int  `json:"answered"`
+	NotAnswered int  `json:"not_answered"`
+}
+
+// GetPresence lists the users connected to the room right now
+func GetPresence(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	roomID := c.Param("id")
+	userID := user.(models.User).ID
+	if err := access.Require(roomID, userID, access.View); err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	summary := PresenceSummary{Connected: websocket.Connected(roomID)}
+
+	// Activity times could be lined up with ballots, so only moderators see them
+	if !access.Can(roomID, userID, access.Moderate) {
+		for i := range summary.Connected {
+			summary.Connected[i].LastActiveAt = nil
+		}
+	}
+
+	turnout, err := currentPollTurnout(roomID, summary.Connected)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load poll turnout"})
+		return
+	}
+	summary.Poll = turnout
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// currentPollTurnout counts which of the connected audience have answered the
+// room's running poll. It returns nil when no poll is running.
+func currentPollTurnout(roomID string, connected []websocket.Presence) (*PollTurnout, error) {
+	var poll models.Poll
+	err := database.DB.Select("id", "anonymous").
+		Where("room_id = ? AND status IN ?", roomID, []string{models.PollStatusLive, models.PollStatusPaused}).
+		Order("start_time DESC").
+		First(&poll).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var audience []uint
+	for _, presence := range connected {
+		if presence.Audience {
+			audience = append(audience, presence.UserID)
+		}
+	}
+
+	turnout := &PollTurnout{PollID: poll.ID, Connected: len(audience)}
+	if len(audience) == 0 {
+		return turnout, nil
+	}
+
+	// Anonymous ballots carry no user; their voters are known from receipts
+	var answered []uint
+	if poll.Anonymous {
+		err = database.DB.Model(&models.VoteReceipt{}).
+			Where("poll_id = ? AND user_id IN ?", poll.ID, audience).
+			Pluck("user_id", &answered).Error
+	} else {
+		err = database.DB.Model(&models.Vote{}).
+			Where("poll_id = ? AND user_id IN ?", poll.ID, audience).
+			Distinct().
+			Pluck("user_id", &answered).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	turnout.Answered = len(answered)
+	turnout.NotAnswered = turnout.Connected - turnout.Answered
+	return turnout, nil
+}
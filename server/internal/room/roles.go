@@ -0,0 +1,283 @@
+package room
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"polling-app/internal/access"
+	"polling-app/internal/apperror"
+	"polling-app/internal/models"
+	"polling-app/internal/websocket"
+	"polling-app/pkg/database"
+)
+
+type SetRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=co_host moderator participant viewer"`
+}
+
+type TransferOwnershipRequest struct {
+	UserID uint `json:"user_id" binding:"required"`
+}
+
+// Member is a room participant with their role
+type Member struct {
+	UserID   uint      `json:"user_id"`
+	Name     string    `json:"name"`
+	IsGuest  bool      `json:"is_guest"`
+	Role     string    `json:"role"`
+	Invite   string    `json:"invite,omitempty"` // Name of the invite link used; shown to invite managers
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// RoleChangedEvent tells the room that a member's role changed
+type RoleChangedEvent struct {
+	RoomID string `json:"room_id"`
+	UserID uint   `json:"user_id"`
+	Role   string `json:"role"`
+}
+
+// ListMembers lists a room's participants and their roles, most privileged first
+func ListMembers(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+
+	currentUser := user.(models.User)
+
+	roomID := c.Param("id")
+	if err := access.Require(roomID, currentUser.ID, access.View); err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	var members []Member
+	err := database.DB.Table("room_participants").
+		Select("users.id AS user_id, users.name, users.is_guest, room_participants.role, room_invites.name AS invite, room_participants.created_at AS joined_at").
+		Joins("JOIN users ON users.id = room_participants.user_id").
+		Joins("LEFT JOIN room_invites ON room_invites.id = room_participants.invite_id").
+		Where("room_participants.room_id = ?", roomID).
+		Order("room_participants.created_at ASC").
+		Scan(&members).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load members"})
+		return
+	}
+
+	if !access.Can(roomID, currentUser.ID, access.ManageInvites) {
+		for i := range members {
+			members[i].Invite = ""
+		}
+	}
+
+	sortMembers(members)
+	c.JSON(http.StatusOK, members)
+}
+
+// sortMembers orders members by role, keeping the join order within each role
+func sortMembers(members []Member) {
+	sort.SliceStable(members, func(i, j int) bool {
+		return models.RoleRank(members[i].Role) > models.RoleRank(members[j].Role)
+	})
+}
+
+// SetRole grants a member a role. Members who manage roles may only change
+// members ranked below them, and only to roles below their own.
+func SetRole(c *gin.Context) {
+	var req SetRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	changeRole(c, req.Role, false)
+}
+
+// RevokeRole returns a member to the participant role. Members who are already
+// participants or viewers have no role to revoke.
+func RevokeRole(c *gin.Context) {
+	changeRole(c, models.RoleParticipant, true)
+}
+
+func changeRole(c *gin.Context, role string, revoke bool) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+	currentUser := user.(models.User)
+
+	roomID := c.Param("id")
+	targetID, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := setRole(roomID, currentUser, uint(targetID), role, revoke); err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, RoleChangedEvent{RoomID: roomID, UserID: uint(targetID), Role: role})
+}
+
+func setRole(roomID string, actor models.User, targetID uint, role string, revoke bool) error {
+	if err := access.Require(roomID, actor.ID, access.ManageRoles); err != nil {
+		return err
+	}
+	if targetID == actor.ID {
+		return apperror.New(http.StatusBadRequest, "You cannot change your own role")
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var actorMember, target models.RoomParticipant
+		if err := tx.Where("room_id = ? AND user_id = ?", roomID, actor.ID).First(&actorMember).Error; err != nil {
+			return apperror.New(http.StatusForbidden, "Not a participant in this room")
+		}
+
+		// Seats are counted with the room row locked, before the member's,
+		// in the same order joins take them
+		free := -1
+		if !models.IsStaff(role) {
+			var err error
+			if free, err = freeSeats(tx, roomID); err != nil {
+				return err
+			}
+		}
+
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("room_id = ? AND user_id = ?", roomID, targetID).
+			First(&target).Error
+		if err != nil {
+			return apperror.New(http.StatusNotFound, "Member not found")
+		}
+		if revoke && models.RoleRank(target.Role) <= models.RoleRank(models.RoleParticipant) {
+			return apperror.New(http.StatusBadRequest, "Member has no role to revoke")
+		}
+
+		actorRank := models.RoleRank(actorMember.Role)
+		if models.RoleRank(target.Role) >= actorRank || models.RoleRank(role) >= actorRank {
+			return apperror.New(http.StatusForbidden, "You can only manage roles below your own")
+		}
+
+		// Staff returning to the audience need a seat like anyone joining
+		if models.IsStaff(target.Role) && free == 0 {
+			return apperror.New(http.StatusConflict, "The room is full; free a seat before moving this member to the audience")
+		}
+
+		return tx.Model(&target).Update("role", role).Error
+	})
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) {
+		return err
+	}
+	if err != nil {
+		return apperror.New(http.StatusInternalServerError, "Failed to update role")
+	}
+
+	broadcastRoleChange(roomID, targetID, role)
+
+	// Promoting an audience member to staff frees their seat
+	admitWaitlist(roomID)
+	broadcastCapacity(roomID)
+	return nil
+}
+
+// TransferOwnership makes another member the owner of the room. The previous
+// owner stays on as a co-host.
+func TransferOwnership(c *gin.Context) {
+	var req TransferOwnershipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+	currentUser := user.(models.User)
+
+	roomID := c.Param("id")
+	if err := access.Require(roomID, currentUser.ID, access.TransferOwnership); err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+	if req.UserID == currentUser.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You already own this room"})
+		return
+	}
+
+	var room models.Room
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&room, "id = ?", roomID).Error; err != nil {
+			return apperror.New(http.StatusNotFound, "Room not found")
+		}
+		if room.HostID != currentUser.ID {
+			return apperror.New(http.StatusForbidden, "You are not allowed to transfer this room")
+		}
+
+		var target models.RoomParticipant
+		if err := tx.Where("room_id = ? AND user_id = ?", roomID, req.UserID).First(&target).Error; err != nil {
+			return apperror.New(http.StatusNotFound, "Member not found")
+		}
+
+		var newOwner models.User
+		if err := tx.First(&newOwner, req.UserID).Error; err != nil {
+			return apperror.New(http.StatusNotFound, "Member not found")
+		}
+		if newOwner.IsGuest {
+			return apperror.New(http.StatusBadRequest, "Guests cannot own a room")
+		}
+
+		if err := tx.Model(&models.RoomParticipant{}).
+			Where("room_id = ? AND user_id = ?", roomID, currentUser.ID).
+			Update("role", models.RoleCoHost).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&target).Update("role", models.RoleOwner).Error; err != nil {
+			return err
+		}
+
+		room.HostID = req.UserID
+		return tx.Model(&room).Update("host_id", req.UserID).Error
+	})
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) {
+		apperror.Respond(c, err)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to transfer room"})
+		return
+	}
+
+	broadcastRoleChange(roomID, currentUser.ID, models.RoleCoHost)
+	broadcastRoleChange(roomID, req.UserID, models.RoleOwner)
+
+	// The new owner may have been in the audience
+	admitWaitlist(roomID)
+	broadcastCapacity(roomID)
+
+	c.JSON(http.StatusOK, room)
+}
+
+// broadcastRoleChange tells the room about a member's new role and updates
+// whether their open connections take up a seat
+func broadcastRoleChange(roomID string, userID uint, role string) {
+	websocket.SetAudience(roomID, userID, !models.IsStaff(role))
+	websocket.BroadcastToRoom(roomID, websocket.EventRoleChanged, RoleChangedEvent{
+		RoomID: roomID,
+		UserID: userID,
+		Role:   role,
+	})
+}
@@ -0,0 +1,58 @@
+package room
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"polling-app/internal/access"
+	"polling-app/internal/apperror"
+	"polling-app/internal/models"
+	"polling-app/internal/testdb"
+	"polling-app/pkg/database"
+)
+
+func TestDemotingStaffNeedsAFreeSeat(t *testing.T) {
+	testdb.Open(t)
+
+	var users [3]models.User
+	for i := range users {
+		users[i] = models.User{Email: fmt.Sprintf("user%d@example.com", i), Password: "x", Name: fmt.Sprintf("User %d", i)}
+		if err := database.DB.Create(&users[i]).Error; err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+	owner, moderator, participant := users[0], users[1], users[2]
+
+	// One seat, already taken by the participant
+	room := models.Room{Name: "Test", HostID: owner.ID, MaxParticipants: 1}
+	if err := database.DB.Create(&room).Error; err != nil {
+		t.Fatalf("create room: %v", err)
+	}
+	for userID, role := range map[uint]string{
+		owner.ID:       models.RoleOwner,
+		moderator.ID:   models.RoleModerator,
+		participant.ID: models.RoleParticipant,
+	} {
+		if err := addMember(database.DB, room.ID, userID, role, nil); err != nil {
+			t.Fatalf("add member: %v", err)
+		}
+	}
+
+	err := setRole(room.ID, owner, moderator.ID, models.RoleParticipant, true)
+	if apperror.StatusOf(err) != http.StatusConflict {
+		t.Fatalf("demoting into a full room: got %v, want a 409", err)
+	}
+
+	if err := database.DB.Model(&room).Update("max_participants", 2).Error; err != nil {
+		t.Fatalf("raise capacity: %v", err)
+	}
+	if err := setRole(room.ID, owner, moderator.ID, models.RoleParticipant, true); err != nil {
+		t.Fatalf("demoting with a free seat: %v", err)
+	}
+
+	role, err := access.RoleOf(room.ID, moderator.ID)
+	if err != nil || role != models.RoleParticipant {
+		t.Errorf("role %q (%v), want %q", role, err, models.RoleParticipant)
+	}
+}
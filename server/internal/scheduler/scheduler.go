@@ -0,0 +1,74 @@
+package scheduler
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// The scheduler keeps in-memory timers for deadlines that are persisted
+// elsewhere (e.g. Poll.EndTime). Timers are lost on restart, so owners of the
+// deadlines re-arm them at startup and register a periodic sweep that catches
+// anything missed. What a timer fires is broadcast through the in-memory
+// WebSocket hub, which is why the server runs as a single instance (see
+// database.lockInstance).
+
+var timers = make(map[string]*time.Timer)
+var timersMu sync.Mutex
+
+// Schedule runs fn at the given time. Scheduling a key that is already armed
+// replaces the previous timer. Deadlines in the past fire immediately.
+func Schedule(key string, at time.Time, fn func()) {
+	timersMu.Lock()
+	defer timersMu.Unlock()
+
+	if existing, ok := timers[key]; ok {
+		existing.Stop()
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(time.Until(at), func() {
+		timersMu.Lock()
+		if timers[key] == timer {
+			delete(timers, key)
+		}
+		timersMu.Unlock()
+
+		run(key, fn)
+	})
+	timers[key] = timer
+}
+
+// Cancel stops the timer for key if one is armed
+func Cancel(key string) {
+	timersMu.Lock()
+	defer timersMu.Unlock()
+
+	if existing, ok := timers[key]; ok {
+		existing.Stop()
+		delete(timers, key)
+	}
+}
+
+// Every calls fn on a fixed interval for the lifetime of the process
+func Every(interval time.Duration, name string, fn func()) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			run(name, fn)
+		}
+	}()
+}
+
+// run executes a job, recovering from panics so one failing job does not take
+// down the process or stop a sweep loop
+func run(name string, fn func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Scheduled job %s panicked: %v", name, r)
+		}
+	}()
+	fn()
+}
@@ -1,48 +1,158 @@
 package websocket
 
 import (
-	"encoding/json"
 	"log"
+	"time"
 )
 
+// lookupRoom returns the hub of a room, or nil if nobody is connected to it
+func lookupRoom(roomID string) *Room {
+	roomsMu.RLock()
+	defer roomsMu.RUnlock()
+	return rooms[roomID]
+}
+
 // BroadcastToRoom sends a message to all clients in a specific room
 func BroadcastToRoom(roomID string, messageType string, payload interface{}) {
-	roomsMu.RLock()
-	room, exists := rooms[roomID]
-	roomsMu.RUnlock()
+	BroadcastTracked(roomID, messageType, payload, nil)
+}
 
-	if !exists {
+// BroadcastTracked sends a message to every connection in a room and calls
+// onWrite for each once the message has been written to its socket. A user
+// with several connections is reported once per connection.
+func BroadcastTracked(roomID string, messageType string, payload interface{}, onWrite func(userID uint, at time.Time)) {
+	room := lookupRoom(roomID)
+	if room == nil {
 		log.Printf("Room %s not found for broadcasting", roomID)
 		return
 	}
 
-	message := Message{
-		Type: messageType,
+	msgBytes, err := encodeMessage(messageType, "", payload)
+	if err != nil {
+		log.Printf("Error marshaling message: %v", err)
+		return
 	}
 
-	payloadBytes, err := json.Marshal(payload)
-	if err != nil {
-		log.Printf("Error marshaling payload: %v", err)
+	room.broadcast(Frame{Data: msgBytes, OnWrite: onWrite})
+}
+
+// SendToUsers sends a message to every connection of the given users in a
+// room, leaving the rest of the room out
+func SendToUsers(roomID string, userIDs []uint, messageType string, payload interface{}) {
+	room := lookupRoom(roomID)
+	if room == nil {
 		return
 	}
-	message.Payload = payloadBytes
 
-	msgBytes, err := json.Marshal(message)
+	msgBytes, err := encodeMessage(messageType, "", payload)
 	if err != nil {
 		log.Printf("Error marshaling message: %v", err)
 		return
 	}
 
+	frame := Frame{Data: msgBytes}
+	var slow []*Client
+	room.mu.RLock()
+	for _, userID := range userIDs {
+		for _, client := range room.Users[userID] {
+			select {
+			case client.Send <- frame:
+			default:
+				slow = append(slow, client)
+			}
+		}
+	}
+	room.mu.RUnlock()
+
+	dropSlow(slow)
+}
+
+// AudienceConnections counts the users connected to a room that take up a
+// seat, each once however many connections they hold
+func AudienceConnections(roomID string) int {
+	room := lookupRoom(roomID)
+	if room == nil {
+		return 0
+	}
+
 	room.mu.RLock()
 	defer room.mu.RUnlock()
+	return room.audience()
+}
+
+// SetAudience changes whether a user's open connections to a room take up a
+// seat, after their role in it changed
+func SetAudience(roomID string, userID uint, audience bool) {
+	room := lookupRoom(roomID)
+	if room == nil {
+		return
+	}
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	for _, client := range room.Users[userID] {
+		client.Audience = audience
+	}
+}
 
+// DisconnectUser ends every connection a user has to a room. The given event
+// is sent to each as the last frame before it is closed.
+func DisconnectUser(roomID string, userID uint, messageType string, payload interface{}) {
+	room := lookupRoom(roomID)
+	if room == nil {
+		return
+	}
+
+	room.mu.RLock()
+	clients := make([]*Client, 0, len(room.Users[userID]))
+	for _, client := range room.Users[userID] {
+		clients = append(clients, client)
+	}
+	room.mu.RUnlock()
+
+	sendFinal(clients, messageType, payload)
+}
+
+// DisconnectRoom sends the given event to every connection in a room as its
+// last frame, closes the connections and forgets the room
+func DisconnectRoom(roomID string, messageType string, payload interface{}) {
+	roomsMu.Lock()
+	room, exists := rooms[roomID]
+	delete(rooms, roomID)
+	roomsMu.Unlock()
+
+	if !exists {
+		return
+	}
+
+	room.mu.RLock()
+	clients := make([]*Client, 0, len(room.Clients))
 	for _, client := range room.Clients {
+		clients = append(clients, client)
+	}
+	room.mu.RUnlock()
+
+	sendFinal(clients, messageType, payload)
+}
+
+// sendFinal queues a closing frame for each client. The connections close once
+// it is written, or straight away if it cannot be queued; their read loops
+// then remove them from the hub.
+func sendFinal(clients []*Client, messageType string, payload interface{}) {
+	msgBytes, err := encodeMessage(messageType, "", payload)
+	if err != nil {
+		log.Printf("Error marshaling message: %v", err)
+	}
+
+	for _, client := range clients {
+		if err != nil {
+			client.close()
+			continue
+		}
 		select {
-		case client.Send <- msgBytes:
+		case client.Send <- Frame{Data: msgBytes, Close: true}:
 		default:
-			close(client.Send)
-			delete(room.Clients, client.ID)
-			client.Conn.Close()
+			client.close()
 		}
 	}
-} 
\ No newline at end of file
+}
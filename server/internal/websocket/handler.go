@@ -3,10 +3,16 @@ package websocket
 import (
 	"encoding/json"
 	"log"
+	"net/http"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	"polling-app/internal/access"
+	"polling-app/internal/apperror"
+	"polling-app/internal/auth"
 	"polling-app/internal/models"
 	"polling-app/pkg/database"
 )
@@ -17,29 +23,61 @@ var upgrader = websocket.Upgrader{
 	CheckOrigin: func(r *http.Request) bool {
 		return true // Allow all origins in development
 	},
+	// Browsers offer the ticket as a subprotocol; the server must select a
+	// different one so the ticket is never echoed back
+	Subprotocols: []string{auth.WebSocketProtocol},
 }
 
+// Client is one WebSocket connection. A user with several tabs or devices
+// open has one Client for each.
 type Client struct {
-	ID     uint
-	RoomID string
-	Conn   *websocket.Conn
-	Send   chan []byte
+	ID       uint64 // Identifies the connection, unique for the process lifetime
+	UserID   uint
+	User     models.User
+	RoomID   string
+	Conn     *websocket.Conn
+	Send     chan Frame
+	Audience bool // Takes up a seat; staff connections do not
+
+	hub       *Room
+	presence  presence
+	lastSeen  atomic.Int64  // Unix nanoseconds of the last message or pong
+	done      chan struct{} // Closed when the connection is shutting down
+	closeOnce sync.Once
 }
 
+// Frame is a queued outbound message. OnWrite, when set, is called with the
+// time the frame was actually written to the client's socket. Close ends the
+// connection once the frame has been written.
+type Frame struct {
+	Data    []byte
+	OnWrite func(userID uint, at time.Time)
+	Close   bool
+}
+
+// Room is the hub of a room's connections. Clients holds every connection;
+// Users groups the same connections by user. Both are guarded by mu.
 type Room struct {
-	ID       string
-	Clients  map[uint]*Client
-	mu       sync.RWMutex
+	ID      string
+	Clients map[uint64]*Client
+	Users   map[uint]map[uint64]*Client
+	mu      sync.RWMutex
 }
 
+// Message is the envelope for every frame. ID is set by the client on
+// commands and echoed back on the matching ack or error frame.
 type Message struct {
 	Type    string          `json:"type"`
-	Payload json.RawMessage `json:"payload"`
+	ID      string          `json:"id,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
 }
 
 var rooms = make(map[string]*Room)
 var roomsMu sync.RWMutex
 
+// lastConnID is the ID given to the most recent connection
+var lastConnID uint64
+
 func HandleWebSocket(c *gin.Context) {
 	roomID := c.Param("id")
 	user, exists := c.Get("user")
@@ -56,10 +94,19 @@ func HandleWebSocket(c *gin.Context) {
 		return
 	}
 
-	var isParticipant bool
-	database.DB.Model(&room).Where("user_id = ?", currentUser.ID).Association("Participants").Count(&isParticipant)
-	if !isParticipant {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Not a participant in this room"})
+	if err := access.Require(room.ID, currentUser.ID, access.View); err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	if room.Status != models.RoomStatusOpen {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Room is " + room.Status})
+		return
+	}
+
+	role, err := access.RoleOf(room.ID, currentUser.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
 		return
 	}
 
@@ -70,39 +117,75 @@ func HandleWebSocket(c *gin.Context) {
 	}
 
 	client := &Client{
-		ID:     currentUser.ID,
-		RoomID: roomID,
-		Conn:   conn,
-		Send:   make(chan []byte, 256),
+		ID:       atomic.AddUint64(&lastConnID, 1),
+		UserID:   currentUser.ID,
+		User:     currentUser,
+		RoomID:   roomID,
+		Conn:     conn,
+		Send:     make(chan Frame, 256),
+		Audience: !models.IsStaff(role),
+		presence: newPresence(time.Now()),
+		done:     make(chan struct{}),
 	}
+	client.seen(client.presence.connectedAt)
 
 	// Get or create room
-	roomMu.Lock()
+	roomsMu.Lock()
 	if _, exists := rooms[roomID]; !exists {
-		rooms[roomID] = &Room{
-			ID:      roomID,
-			Clients: make(map[uint]*Client),
-		}
+		rooms[roomID] = newRoom(roomID)
+	}
+	hub := rooms[roomID]
+	hub.mu.Lock()
+	admitted, firstConnection := hub.admit(client, room.MaxParticipants)
+	hub.mu.Unlock()
+	roomsMu.Unlock()
+
+	// The seat check runs under the hub lock, so it can only be answered
+	// after the upgrade; a full room closes the socket straight away
+	if !admitted {
+		conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "Room is full"),
+			time.Now().Add(writeTimeout()))
+		conn.Close()
+		return
+	}
+
+	// Another tab of someone already here is not news to the room
+	if firstConnection {
+		client.broadcastPresence(PresenceJoined, client.presence.connectedAt)
 	}
-	room := rooms[roomID]
-	room.mu.Lock()
-	room.Clients[client.ID] = client
-	room.mu.Unlock()
-	roomMu.Unlock()
 
 	// Start goroutines for reading and writing
 	go client.writePump()
-	go client.readPump(room)
+	go client.readPump()
 }
 
-func (c *Client) readPump(room *Room) {
+// readPump owns the client's place in the hub: whatever ends the connection,
+// the client is removed here, and only here, once its socket is closed
+func (c *Client) readPump() {
 	defer func() {
-		room.mu.Lock()
-		delete(room.Clients, c.ID)
-		room.mu.Unlock()
-		c.Conn.Close()
+		c.close()
+
+		c.hub.mu.Lock()
+		lastConnection := c.hub.remove(c)
+		c.hub.mu.Unlock()
+
+		if lastConnection {
+			c.broadcastPresence(PresenceLeft, time.Now())
+		}
 	}()
 
+	// Browsers answer pings on their own, so a live client keeps pushing its
+	// read deadline back even while its user does nothing
+	wait := pongWait()
+	c.Conn.SetReadLimit(maxMessageSize())
+	c.Conn.SetReadDeadline(time.Now().Add(wait))
+	c.Conn.SetPongHandler(func(string) error {
+		now := time.Now()
+		c.seen(now)
+		return c.Conn.SetReadDeadline(now.Add(wait))
+	})
+
 	for {
 		_, message, err := c.Conn.ReadMessage()
 		if err != nil {
@@ -112,133 +195,64 @@ func (c *Client) readPump(room *Room) {
 			break
 		}
 
+		now := time.Now()
+		c.seen(now)
+		c.Conn.SetReadDeadline(now.Add(wait))
+
 		var msg Message
 		if err := json.Unmarshal(message, &msg); err != nil {
-			log.Printf("error unmarshaling message: %v", err)
+			c.reply(FrameError, "", ErrorPayload{Message: "Malformed message"})
 			continue
 		}
 
-		// Handle different message types
-		switch msg.Type {
-		case "vote":
-			handleVote(c, room, msg.Payload)
-		case "start_poll":
-			handleStartPoll(c, room, msg.Payload)
-		case "end_poll":
-			handleEndPoll(c, room, msg.Payload)
+		// Votes are not activity: an active event or last_active_at landing
+		// next to each ballot would tell the room who cast it
+		if msg.Type != CommandVote && c.hub.touch(c, now) {
+			c.broadcastPresence(PresenceActive, now)
 		}
+
+		// Commands are validated and executed by their registered handler;
+		// nothing a client sends is relayed to the room as-is
+		c.dispatch(msg)
 	}
 }
 
+// writePump writes each queued message as its own frame, since clients parse
+// every frame as a single JSON message, and pings the client in between.
+// Every write has a deadline, so a client that stops reading is dropped
+// instead of blocking the pump forever.
 func (c *Client) writePump() {
+	ticker := time.NewTicker(pingInterval())
+	timeout := writeTimeout()
 	defer func() {
-		c.Conn.Close()
+		ticker.Stop()
+		c.close()
 	}()
 
 	for {
 		select {
-		case message, ok := <-c.Send:
-			if !ok {
-				c.Conn.WriteMessage(websocket.CloseMessage, []byte{})
+		case <-c.done:
+			return
+		case frame := <-c.Send:
+			c.Conn.SetWriteDeadline(time.Now().Add(timeout))
+			if err := c.Conn.WriteMessage(websocket.TextMessage, frame.Data); err != nil {
 				return
 			}
 
-			w, err := c.Conn.NextWriter(websocket.TextMessage)
-			if err != nil {
-				return
+			if frame.OnWrite != nil {
+				frame.OnWrite(c.UserID, time.Now())
 			}
-			w.Write(message)
 
-			n := len(c.Send)
-			for i := 0; i < n; i++ {
-				w.Write([]byte{'\n'})
-				w.Write(<-c.Send)
+			if frame.Close {
+				c.Conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.ClosePolicyViolation, ""),
+					time.Now().Add(timeout))
+				return
 			}
-
-			if err := w.Close(); err != nil {
+		case <-ticker.C:
+			if err := c.Conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(timeout)); err != nil {
 				return
 			}
 		}
 	}
 }
-
-func handleVote(client *Client, room *Room, payload json.RawMessage) {
-	// Broadcast vote to all clients in the room
-	room.mu.RLock()
-	defer room.mu.RUnlock()
-
-	message := Message{
-		Type:    "vote",
-		Payload: payload,
-	}
-
-	msgBytes, err := json.Marshal(message)
-	if err != nil {
-		log.Printf("error marshaling vote message: %v", err)
-		return
-	}
-
-	for _, c := range room.Clients {
-		select {
-		case c.Send <- msgBytes:
-		default:
-			close(c.Send)
-			delete(room.Clients, c.ID)
-			c.Conn.Close()
-		}
-	}
-}
-
-func handleStartPoll(client *Client, room *Room, payload json.RawMessage) {
-	// Broadcast poll start to all clients
-	room.mu.RLock()
-	defer room.mu.RUnlock()
-
-	message := Message{
-		Type:    "start_poll",
-		Payload: payload,
-	}
-
-	msgBytes, err := json.Marshal(message)
-	if err != nil {
-		log.Printf("error marshaling start poll message: %v", err)
-		return
-	}
-
-	for _, c := range room.Clients {
-		select {
-		case c.Send <- msgBytes:
-		default:
-			close(c.Send)
-			delete(room.Clients, c.ID)
-			c.Conn.Close()
-		}
-	}
-}
-
-func handleEndPoll(client *Client, room *Room, payload json.RawMessage) {
-	// Broadcast poll end to all clients
-	room.mu.RLock()
-	defer room.mu.RUnlock()
-
-	message := Message{
-		Type:    "end_poll",
-		Payload: payload,
-	}
-
-	msgBytes, err := json.Marshal(message)
-	if err != nil {
-		log.Printf("error marshaling end poll message: %v", err)
-		return
-	}
-
-	for _, c := range room.Clients {
-		select {
-		case c.Send <- msgBytes:
-		default:
-			close(c.Send)
-			delete(room.Clients, c.ID)
-			c.Conn.Close()
-		}
-	}
-} 
\ No newline at end of file
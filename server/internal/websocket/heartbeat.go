@@ -0,0 +1,136 @@
+package websocket
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"polling-app/internal/scheduler"
+)
+
+// Defaults used when the WS_* settings are unset or invalid
+const (
+	defaultPingInterval   = 30 * time.Second
+	defaultPongWait       = 60 * time.Second
+	defaultWriteTimeout   = 10 * time.Second
+	defaultMaxMessageSize = 16 * 1024
+	defaultReapInterval   = time.Minute
+)
+
+// pingInterval is how often the server pings each client. It is kept below
+// pongWait so a healthy client always answers before its deadline.
+func pingInterval() time.Duration {
+	interval := durationSetting("WS_PING_INTERVAL", defaultPingInterval)
+	if wait := pongWait(); interval >= wait {
+		return wait * 9 / 10
+	}
+	return interval
+}
+
+// pongWait is how long a connection may stay silent, pongs included, before
+// its read deadline expires
+func pongWait() time.Duration {
+	return durationSetting("WS_PONG_WAIT", defaultPongWait)
+}
+
+// writeTimeout bounds every write to a client's socket
+func writeTimeout() time.Duration {
+	return durationSetting("WS_WRITE_TIMEOUT", defaultWriteTimeout)
+}
+
+// maxMessageSize is the largest message, in bytes, a client may send
+func maxMessageSize() int64 {
+	if value := os.Getenv("WS_MAX_MESSAGE_SIZE"); value != "" {
+		if parsed, err := strconv.ParseInt(value, 10, 64); err == nil && parsed > 0 {
+			return parsed
+		}
+	}
+	return defaultMaxMessageSize
+}
+
+func durationSetting(name string, fallback time.Duration) time.Duration {
+	if value := os.Getenv(name); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed > 0 {
+			return parsed
+		}
+	}
+	return fallback
+}
+
+// seen records that the client was heard from, by a message or a pong
+func (c *Client) seen(at time.Time) {
+	c.lastSeen.Store(at.UnixNano())
+}
+
+// closed reports whether the connection has been shut down
+func (c *Client) closed() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// StartReaper starts the loop that removes dead connections from the hub and
+// frees rooms nobody is connected to. Read deadlines end most half-open
+// connections; the reaper catches any whose read loop never got to clean up.
+func StartReaper() {
+	scheduler.Every(durationSetting("WS_REAP_INTERVAL", defaultReapInterval), "websocket-reaper", reapConnections)
+}
+
+func reapConnections() {
+	cutoff := time.Now().Add(-pongWait()).UnixNano()
+
+	roomsMu.RLock()
+	hubs := make([]*Room, 0, len(rooms))
+	for _, room := range rooms {
+		hubs = append(hubs, room)
+	}
+	roomsMu.RUnlock()
+
+	reaped := 0
+	for _, room := range hubs {
+		var dead, left []*Client
+		room.mu.Lock()
+		for _, client := range room.Clients {
+			if client.closed() || client.lastSeen.Load() < cutoff {
+				dead = append(dead, client)
+				if room.remove(client) {
+					left = append(left, client)
+				}
+			}
+		}
+		room.mu.Unlock()
+
+		// Closing stops the pumps; the read loop's own removal is then a no-op
+		for _, client := range dead {
+			client.close()
+		}
+		now := time.Now()
+		for _, client := range left {
+			client.broadcastPresence(PresenceLeft, now)
+		}
+		reaped += len(dead)
+	}
+
+	// Empty hubs are dropped under the registry lock, which HandleWebSocket
+	// also holds while joining a hub, so nobody can join one being dropped
+	freed := 0
+	roomsMu.Lock()
+	for roomID, room := range rooms {
+		room.mu.RLock()
+		empty := len(room.Clients) == 0
+		room.mu.RUnlock()
+		if empty {
+			delete(rooms, roomID)
+			freed++
+		}
+	}
+	roomsMu.Unlock()
+
+	if reaped > 0 || freed > 0 {
+		log.Printf("Reaped %d dead connections and %d empty rooms", reaped, freed)
+	}
+}
@@ -0,0 +1,143 @@
+package websocket
+
+import (
+	"log"
+	"time"
+)
+
+func newRoom(roomID string) *Room {
+	return &Room{
+		ID:      roomID,
+		Clients: make(map[uint64]*Client),
+		Users:   make(map[uint]map[uint64]*Client),
+	}
+}
+
+// admit registers the client unless it would take the room's connected
+// audience past maxAudience. A user who already has a connection open does
+// not take another seat. It also reports whether this is the user's first
+// connection. The caller holds r.mu.
+func (r *Room) admit(client *Client, maxAudience int) (admitted, first bool) {
+	connections, connected := r.Users[client.UserID]
+	if !connected && client.Audience && maxAudience > 0 && r.audience() >= maxAudience {
+		return false, false
+	}
+
+	if !connected {
+		connections = make(map[uint64]*Client)
+		r.Users[client.UserID] = connections
+	}
+	connections[client.ID] = client
+	r.Clients[client.ID] = client
+	client.hub = r
+	return true, !connected
+}
+
+// remove drops the client from the room and reports whether it was the user's
+// last connection. Removing a client twice is harmless. The caller holds r.mu.
+func (r *Room) remove(client *Client) bool {
+	if _, present := r.Clients[client.ID]; !present {
+		return false
+	}
+	delete(r.Clients, client.ID)
+
+	connections := r.Users[client.UserID]
+	delete(connections, client.ID)
+	if len(connections) > 0 {
+		return false
+	}
+	delete(r.Users, client.UserID)
+	return true
+}
+
+// audience counts the connected users that take up a seat, each once however
+// many connections they hold. The caller holds r.mu.
+func (r *Room) audience() int {
+	count := 0
+	for _, connections := range r.Users {
+		for _, client := range connections {
+			if client.Audience {
+				count++
+				break
+			}
+		}
+	}
+	return count
+}
+
+// userIdle reports whether every connection of the user is idle. The caller
+// holds r.mu.
+func (r *Room) userIdle(userID uint) bool {
+	for _, client := range r.Users[userID] {
+		if !client.presence.isIdle() {
+			return false
+		}
+	}
+	return true
+}
+
+// touch records activity on a connection and reports whether its user was
+// idle until now. The common case, a connection that was not idle, only needs
+// the read lock; waking an idle one takes the write lock so that two tabs
+// waking at once, or the idle sweep, cannot both miss or both announce it.
+func (r *Room) touch(client *Client, now time.Time) bool {
+	r.mu.RLock()
+	if !client.presence.isIdle() {
+		client.presence.touch(now)
+		r.mu.RUnlock()
+		return false
+	}
+	r.mu.RUnlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	wasIdle := r.userIdle(client.UserID)
+	client.presence.touch(now)
+	return wasIdle
+}
+
+// send encodes a message and queues it for every connection in the room
+func (r *Room) send(messageType string, payload interface{}) {
+	msgBytes, err := encodeMessage(messageType, "", payload)
+	if err != nil {
+		log.Printf("Error marshaling message: %v", err)
+		return
+	}
+	r.broadcast(Frame{Data: msgBytes})
+}
+
+// broadcast queues a frame for every connection in the room
+func (r *Room) broadcast(frame Frame) {
+	r.mu.RLock()
+	var slow []*Client
+	for _, client := range r.Clients {
+		select {
+		case client.Send <- frame:
+		default:
+			slow = append(slow, client)
+		}
+	}
+	r.mu.RUnlock()
+
+	dropSlow(slow)
+}
+
+// dropSlow closes connections whose send buffer is full. Their read loops
+// then remove them from the hub.
+func dropSlow(clients []*Client) {
+	for _, client := range clients {
+		log.Printf("Closing connection %d of user %d: send buffer full", client.ID, client.UserID)
+		client.close()
+	}
+}
+
+// close shuts the connection down. Both pumps stop and the read loop removes
+// the client from the hub. It is safe to call more than once, from any
+// goroutine; Send is never closed, so late senders cannot panic.
+func (c *Client) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.Conn.Close()
+	})
+}
@@ -0,0 +1,216 @@
+package websocket
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"polling-app/internal/scheduler"
+)
+
+// defaultIdleAfter is used when PRESENCE_IDLE_AFTER is unset or invalid
+const defaultIdleAfter = 2 * time.Minute
+
+// presenceSweepInterval is how often connections are checked for idleness
+const presenceSweepInterval = 15 * time.Second
+
+// Presence statuses carried by EventPresence
+const (
+	PresenceJoined = "joined"
+	PresenceLeft   = "left"
+	PresenceIdle   = "idle"
+	PresenceActive = "active"
+)
+
+// PresenceEvent tells the room that someone connected, disconnected, went idle
+// or came back
+type PresenceEvent struct {
+	UserID uint      `json:"user_id"`
+	Name   string    `json:"name"`
+	Status string    `json:"status"`
+	At     time.Time `json:"at"`
+}
+
+// Presence describes a user currently connected to a room, across all of
+// their connections. They are idle only while every connection is.
+// LastActiveAt can be matched against ballots, so callers clear it for anyone
+// who may not moderate the room.
+type Presence struct {
+	UserID       uint       `json:"user_id"`
+	Name         string     `json:"name"`
+	IsGuest      bool       `json:"is_guest"`
+	ConnectedAt  time.Time  `json:"connected_at"`
+	LastActiveAt *time.Time `json:"last_active_at,omitempty"`
+	Idle         bool       `json:"idle"`
+	Connections  int        `json:"connections"`
+	Audience     bool       `json:"-"`
+}
+
+// presence is the activity state of one connection. It is read under the
+// hub's read lock while the connection's read loop updates it, so it has its
+// own lock as well.
+type presence struct {
+	mu          sync.Mutex
+	connectedAt time.Time
+	lastActive  time.Time
+	idle        bool
+}
+
+func newPresence(now time.Time) presence {
+	return presence{connectedAt: now, lastActive: now}
+}
+
+// touch records activity and reports whether the client was idle until now
+func (p *presence) touch(now time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	wasIdle := p.idle
+	p.lastActive = now
+	p.idle = false
+	return wasIdle
+}
+
+func (p *presence) isIdle() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.idle
+}
+
+// markIdle flags the client idle if it has been inactive since cutoff, and
+// reports whether that changed anything
+func (p *presence) markIdle(cutoff time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.idle || p.lastActive.After(cutoff) {
+		return false
+	}
+	p.idle = true
+	return true
+}
+
+// merge folds one of the user's connections into their presence
+func (p *Presence) merge(c *Client) {
+	c.presence.mu.Lock()
+	defer c.presence.mu.Unlock()
+
+	if p.Connections == 0 {
+		*p = Presence{
+			UserID:      c.UserID,
+			Name:        c.User.Name,
+			IsGuest:     c.User.IsGuest,
+			ConnectedAt: c.presence.connectedAt,
+			Idle:        true,
+		}
+	}
+
+	p.Connections++
+	if c.presence.connectedAt.Before(p.ConnectedAt) {
+		p.ConnectedAt = c.presence.connectedAt
+	}
+	if p.LastActiveAt == nil || c.presence.lastActive.After(*p.LastActiveAt) {
+		lastActive := c.presence.lastActive
+		p.LastActiveAt = &lastActive
+	}
+	p.Idle = p.Idle && c.presence.idle
+	p.Audience = p.Audience || c.Audience
+}
+
+// broadcastPresence tells the client's room about a change in its user's
+// presence. It goes through the client's own hub, which may already have been
+// dropped from rooms when the room shut down.
+func (c *Client) broadcastPresence(status string, at time.Time) {
+	c.hub.send(EventPresence, PresenceEvent{
+		UserID: c.UserID,
+		Name:   c.User.Name,
+		Status: status,
+		At:     at,
+	})
+}
+
+// Connected lists the users currently connected to a room, earliest first
+func Connected(roomID string) []Presence {
+	roomsMu.RLock()
+	room, exists := rooms[roomID]
+	roomsMu.RUnlock()
+
+	if !exists {
+		return []Presence{}
+	}
+
+	room.mu.RLock()
+	connected := make([]Presence, 0, len(room.Users))
+	for _, connections := range room.Users {
+		var presence Presence
+		for _, client := range connections {
+			presence.merge(client)
+		}
+		connected = append(connected, presence)
+	}
+	room.mu.RUnlock()
+
+	sort.Slice(connected, func(i, j int) bool {
+		return connected[i].ConnectedAt.Before(connected[j].ConnectedAt)
+	})
+	return connected
+}
+
+// StartPresence registers the activity command and starts the sweep that
+// marks quiet connections idle
+func StartPresence() {
+	// Any inbound message other than a vote counts as activity; this command
+	// exists so clients can report user interaction without doing anything else
+	RegisterCommand(CommandActivity, func(client *Client, payload json.RawMessage) (interface{}, error) {
+		return nil, nil
+	})
+
+	scheduler.Every(presenceSweepInterval, "presence-sweep", sweepIdle)
+}
+
+// sweepIdle marks connections idle once they have been inactive for
+// idleAfter, and announces users whose last active connection went quiet. It
+// holds each hub's write lock so it cannot interleave with Room.touch.
+func sweepIdle() {
+	now := time.Now()
+	cutoff := now.Add(-idleAfter())
+
+	roomsMu.RLock()
+	hubs := make([]*Room, 0, len(rooms))
+	for _, room := range rooms {
+		hubs = append(hubs, room)
+	}
+	roomsMu.RUnlock()
+
+	for _, room := range hubs {
+		var idle []*Client
+		room.mu.Lock()
+		for userID, connections := range room.Users {
+			var changed *Client
+			for _, client := range connections {
+				if client.presence.markIdle(cutoff) {
+					changed = client
+				}
+			}
+			if changed != nil && room.userIdle(userID) {
+				idle = append(idle, changed)
+			}
+		}
+		room.mu.Unlock()
+
+		for _, client := range idle {
+			client.broadcastPresence(PresenceIdle, now)
+		}
+	}
+}
+
+func idleAfter() time.Duration {
+	if value := os.Getenv("PRESENCE_IDLE_AFTER"); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed > 0 {
+			return parsed
+		}
+	}
+	return defaultIdleAfter
+}
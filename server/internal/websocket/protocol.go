@@ -0,0 +1,153 @@
+package websocket
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin/binding"
+	"polling-app/internal/apperror"
+)
+
+// Commands a client may send. Every command is answered with either an ack or
+// an error frame carrying the command's ID.
+const (
+	CommandVote         = "vote"
+	CommandStartPoll    = "start_poll"
+	CommandSchedulePoll = "schedule_poll"
+	CommandPausePoll    = "pause_poll"
+	CommandResumePoll   = "resume_poll"
+	CommandExtendPoll   = "extend_poll"
+	CommandEndPoll      = "end_poll"
+	CommandRevealPoll   = "reveal_poll"
+	CommandShowRunoff   = "show_runoff_round"
+	CommandModerate     = "moderate_response"
+
+	CommandAskQuestion      = "ask_question"
+	CommandUpvoteQuestion   = "upvote_question"
+	CommandRemoveUpvote     = "remove_upvote"
+	CommandModerateQuestion = "moderate_question"
+
+	CommandActivity = "activity"
+)
+
+// Events the server broadcasts to a room. Only the server generates them.
+const (
+	EventStartPoll       = "start_poll"
+	EventSchedulePoll    = "schedule_poll"
+	EventPausePoll       = "pause_poll"
+	EventResumePoll      = "resume_poll"
+	EventExtendPoll      = "extend_poll"
+	EventEndPoll         = "end_poll"
+	EventRevealPoll      = "reveal_poll"
+	EventLeaderboard     = "leaderboard"
+	EventRunoffRound     = "runoff_round"
+	EventTextResponse    = "text_response"
+	EventPendingResponse = "pending_response"
+	EventResponseHidden  = "response_hidden"
+	EventResultsUpdate   = "results_update"
+	EventQuestions       = "questions"
+	EventRoleChanged     = "role_changed"
+	EventMemberRemoved   = "member_removed"
+	EventMemberMuted     = "member_muted"
+	EventKicked          = "kicked"
+	EventRoomClosed      = "room_closed"
+	EventJoinQueue       = "join_queue"
+	EventCapacity        = "capacity"
+	EventPresence        = "presence"
+)
+
+// Frames the server sends in reply to a command
+const (
+	FrameAck   = "ack"
+	FrameError = "error"
+)
+
+// CommandHandler executes a validated command on behalf of a client. The
+// returned value is sent back in the ack frame; a returned error is sent back
+// in an error frame. Handlers broadcast any resulting events themselves.
+type CommandHandler func(client *Client, payload json.RawMessage) (interface{}, error)
+
+type ErrorPayload struct {
+	Message string `json:"message"`
+}
+
+var commands = make(map[string]CommandHandler)
+var commandsMu sync.RWMutex
+
+// RegisterCommand installs the handler for an inbound command type. Packages
+// that own the underlying services register their commands at startup, which
+// keeps this package free of imports on them.
+func RegisterCommand(commandType string, handler CommandHandler) {
+	commandsMu.Lock()
+	defer commandsMu.Unlock()
+	commands[commandType] = handler
+}
+
+// DecodePayload parses a command payload and applies the same binding rules
+// the REST handlers use for request bodies
+func DecodePayload(payload json.RawMessage, cmd interface{}) error {
+	if len(payload) == 0 {
+		return apperror.New(http.StatusBadRequest, "Missing payload")
+	}
+	if err := json.Unmarshal(payload, cmd); err != nil {
+		return apperror.New(http.StatusBadRequest, "Malformed payload")
+	}
+	if err := binding.Validator.ValidateStruct(cmd); err != nil {
+		return apperror.New(http.StatusBadRequest, err.Error())
+	}
+	return nil
+}
+
+// dispatch runs the handler registered for msg.Type and replies to the sender
+func (c *Client) dispatch(msg Message) {
+	commandsMu.RLock()
+	handler, exists := commands[msg.Type]
+	commandsMu.RUnlock()
+
+	if !exists {
+		c.reply(FrameError, msg.ID, ErrorPayload{Message: "Unknown command"})
+		return
+	}
+
+	result, err := handler(c, msg.Payload)
+	if err != nil {
+		c.reply(FrameError, msg.ID, ErrorPayload{Message: err.Error()})
+		return
+	}
+
+	c.reply(FrameAck, msg.ID, result)
+}
+
+// reply sends a frame to this client only
+func (c *Client) reply(frameType, id string, payload interface{}) {
+	msgBytes, err := encodeMessage(frameType, id, payload)
+	if err != nil {
+		log.Printf("Error marshaling %s frame: %v", frameType, err)
+		return
+	}
+
+	select {
+	case c.Send <- Frame{Data: msgBytes}:
+	default:
+		log.Printf("Dropping %s frame for client %d: send buffer full", frameType, c.ID)
+	}
+}
+
+func encodeMessage(messageType, id string, payload interface{}) ([]byte, error) {
+	message := Message{
+		Type: messageType,
+		ID:   id,
+	}
+
+	if payload != nil {
+		payloadBytes, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		message.Payload = payloadBytes
+	}
+
+	return json.Marshal(message)
+}
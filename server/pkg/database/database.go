@@ -26,18 +26,98 @@ func InitDB() {
 		log.Fatal("Failed to connect to database:", err)
 	}
 
+	if err := lockInstance(db); err != nil {
+		log.Fatal("Failed to start:", err)
+	}
+
+	if err := Migrate(db); err != nil {
+		log.Fatal("Failed to migrate database:", err)
+	}
+
+	DB = db
+	log.Println("Database connected successfully")
+}
+
+// Migrate brings the schema up to date and backfills data that older versions
+// stored differently. Steps that AutoMigrate cannot do on its own run first.
+func Migrate(db *gorm.DB) error {
+	// Room participants carry a role, so the join table has its own model
+	if err := db.SetupJoinTable(&models.Room{}, "Participants", &models.RoomParticipant{}); err != nil {
+		return fmt.Errorf("set up room participants: %w", err)
+	}
+
+	if err := dropLegacyUserConstraints(db); err != nil {
+		return fmt.Errorf("drop legacy user constraints: %w", err)
+	}
+
+	if err := relaxVoteColumns(db); err != nil {
+		return fmt.Errorf("relax vote columns: %w", err)
+	}
+
+	if err := dedupeVotes(db); err != nil {
+		return fmt.Errorf("remove duplicate votes: %w", err)
+	}
+
+	// Polls from before lifecycle states get their status from is_active
+	// once AutoMigrate has added the column
+	backfillPollStatus := db.Migrator().HasTable(&models.Poll{}) && !db.Migrator().HasColumn(&models.Poll{}, "Status")
+
 	// Auto migrate the schema
-	err = db.AutoMigrate(
+	err := db.AutoMigrate(
 		&models.User{},
 		&models.Room{},
+		&models.RoomParticipant{},
+		&models.RoomBan{},
+		&models.RoomInvite{},
+		&models.JoinRequest{},
 		&models.Poll{},
 		&models.Option{},
 		&models.Vote{},
+		&models.Selection{},
+		&models.VoteReceipt{},
+		&models.VoteRevision{},
+		&models.WebSocketTicket{},
+		&models.Question{},
+		&models.QuestionUpvote{},
 	)
 	if err != nil {
-		log.Fatal("Failed to migrate database:", err)
+		return fmt.Errorf("migrate schema: %w", err)
 	}
 
-	DB = db
-	log.Println("Database connected successfully")
-} 
\ No newline at end of file
+	if backfillPollStatus {
+		err = db.Exec(`UPDATE polls SET
+			status = CASE WHEN is_active THEN ? ELSE ? END,
+			closed_at = CASE WHEN is_active THEN NULL ELSE end_time END`,
+			models.PollStatusLive, models.PollStatusClosed).Error
+		if err != nil {
+			return fmt.Errorf("backfill poll statuses: %w", err)
+		}
+	}
+
+	// Single choice ballots from before multiple selection only have
+	// votes.option_id; results are counted from selections
+	err = db.Exec(`INSERT INTO selections (vote_id, poll_id, option_id, rank)
+		SELECT votes.id, votes.poll_id, votes.option_id, 0 FROM votes
+		WHERE votes.option_id IS NOT NULL
+		AND NOT EXISTS (SELECT 1 FROM selections WHERE selections.vote_id = votes.id)`).Error
+	if err != nil {
+		return fmt.Errorf("backfill vote selections: %w", err)
+	}
+
+	// Hosts of rooms created before roles existed joined as participants
+	err = db.Model(&models.RoomParticipant{}).
+		Where("role <> ? AND (room_id, user_id) IN (SELECT id, host_id FROM rooms)", models.RoleOwner).
+		Update("role", models.RoleOwner).Error
+	if err != nil {
+		return fmt.Errorf("backfill room owners: %w", err)
+	}
+
+	// Rooms created before invite links existed only have Room.InviteCode
+	err = db.Exec(`INSERT INTO room_invites (room_id, code, name, is_default, max_uses, uses, created_by_id, created_at, updated_at)
+		SELECT id, invite_code, ?, true, 0, 0, host_id, NOW(), NOW() FROM rooms
+		WHERE invite_code NOT IN (SELECT code FROM room_invites)`, models.DefaultInviteName).Error
+	if err != nil {
+		return fmt.Errorf("backfill room invites: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// instanceLockKey is the Postgres advisory lock the running server holds
+const instanceLockKey int64 = 0x706f6c6c // "poll"
+
+// instanceConn keeps the advisory lock's session open for the life of the
+// process
+var instanceConn *sql.Conn
+
+// lockInstance makes sure only one server runs against the database. Each
+// room's WebSocket hub lives in the memory of the server its clients are
+// connected to, so broadcasts, including polls closed by a deadline timer,
+// would never reach clients of a second instance. The lock is tied to one
+// session, which is released when the process exits.
+func lockInstance(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", instanceLockKey).Scan(&locked); err != nil {
+		conn.Close()
+		return err
+	}
+	if !locked {
+		conn.Close()
+		return errors.New("another server is already running against this database; only one instance is supported")
+	}
+
+	instanceConn = conn
+	return nil
+}
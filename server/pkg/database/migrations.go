@@ -0,0 +1,87 @@
+package database
+
+import (
+	"gorm.io/gorm"
+	"polling-app/internal/models"
+)
+
+// dropLegacyUserConstraints removes the plain unique constraints users.email
+// and users.google_id had before guests existed. Guests have neither, so the
+// old constraints reject every guest after the first; partial unique indexes
+// that ignore empty values replace them. It must run before AutoMigrate, which
+// would otherwise keep an old index that already has the new index's name.
+func dropLegacyUserConstraints(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&models.User{}) {
+		return nil
+	}
+
+	// Postgres names inline UNIQUE constraints table_column_key; newer GORM
+	// versions name them uni_table_column
+	for _, constraint := range []string{"users_email_key", "uni_users_email", "users_google_id_key", "uni_users_google_id"} {
+		if err := db.Exec("ALTER TABLE users DROP CONSTRAINT IF EXISTS " + constraint).Error; err != nil {
+			return err
+		}
+	}
+
+	var stale []string
+	err := db.Raw(`SELECT indexname FROM pg_indexes
+		WHERE tablename = 'users' AND indexname IN ('idx_users_email', 'idx_users_google_id')
+		AND indexdef NOT LIKE '%WHERE%'`).Scan(&stale).Error
+	if err != nil {
+		return err
+	}
+	for _, index := range stale {
+		if err := db.Exec("DROP INDEX IF EXISTS " + index).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dedupeVotes keeps only the earliest ballot of each voter on a poll and
+// deletes the selections and revisions of the others. Before votes were
+// recorded in a transaction, two racing requests could both store a ballot,
+// and such duplicates would stop AutoMigrate creating idx_votes_poll_voter.
+// It runs before AutoMigrate, and only while that index is missing.
+func dedupeVotes(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&models.Vote{}) || db.Migrator().HasIndex(&models.Vote{}, "idx_votes_poll_voter") {
+		return nil
+	}
+
+	duplicates := `SELECT id FROM votes v WHERE v.user_id IS NOT NULL AND EXISTS (
+		SELECT 1 FROM votes e WHERE e.poll_id = v.poll_id AND e.user_id = v.user_id
+		AND (e.created_at, e.id) < (v.created_at, v.id))`
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		// Children go first, while the duplicates can still be found
+		for _, table := range []string{"selections", "vote_revisions"} {
+			if !tx.Migrator().HasTable(table) {
+				continue
+			}
+			if err := tx.Exec("DELETE FROM " + table + " WHERE vote_id IN (" + duplicates + ")").Error; err != nil {
+				return err
+			}
+		}
+		return tx.Exec("DELETE FROM votes WHERE id IN (" + duplicates + ")").Error
+	})
+}
+
+// relaxVoteColumns lets votes.user_id and votes.option_id hold NULL. Both were
+// created NOT NULL, but anonymous ballots have no voter and only single
+// choice ballots have an option. AutoMigrate adds NOT NULL to a column but
+// never drops it, so this runs before it.
+func relaxVoteColumns(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&models.Vote{}) {
+		return nil
+	}
+
+	for _, column := range []string{"user_id", "option_id"} {
+		if !db.Migrator().HasColumn(&models.Vote{}, column) {
+			continue
+		}
+		if err := db.Exec("ALTER TABLE votes ALTER COLUMN " + column + " DROP NOT NULL").Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
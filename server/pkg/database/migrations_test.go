@@ -0,0 +1,52 @@
+package database_test
+
+import (
+	"testing"
+
+	"polling-app/internal/models"
+	"polling-app/internal/testdb"
+	"polling-app/pkg/database"
+)
+
+func TestMigrateRelaxesLegacyVoteColumns(t *testing.T) {
+	db := testdb.Empty(t)
+
+	// The votes table as the first release created it
+	err := db.Exec(`CREATE TABLE votes (
+		id bigserial PRIMARY KEY,
+		user_id bigint NOT NULL,
+		poll_id bigint NOT NULL,
+		option_id bigint NOT NULL,
+		time_taken decimal NOT NULL,
+		created_at timestamptz
+	)`).Error
+	if err != nil {
+		t.Fatalf("create legacy votes: %v", err)
+	}
+
+	// Migrating an up to date schema again must be harmless
+	for i := 0; i < 2; i++ {
+		if err := database.Migrate(db); err != nil {
+			t.Fatalf("migrate: %v", err)
+		}
+	}
+
+	host := models.User{Email: "host@example.com", Password: "x"}
+	if err := db.Create(&host).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	room := models.Room{Name: "Test", HostID: host.ID}
+	if err := db.Create(&room).Error; err != nil {
+		t.Fatalf("create room: %v", err)
+	}
+	poll := models.Poll{RoomID: room.ID, Question: "Why?", Duration: 60, Type: models.PollTypeText}
+	if err := db.Create(&poll).Error; err != nil {
+		t.Fatalf("create poll: %v", err)
+	}
+
+	// An anonymous text answer has neither a voter nor an option
+	vote := models.Vote{PollID: poll.ID, Text: "Because"}
+	if err := db.Create(&vote).Error; err != nil {
+		t.Fatalf("insert ballot without voter or option: %v", err)
+	}
+}